@@ -0,0 +1,95 @@
+package eval
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/pulzeai-oss/knn-router/internal/eval"
+	"github.com/pulzeai-oss/knn-router/internal/loader"
+	"github.com/pulzeai-oss/knn-router/internal/server"
+	"github.com/pulzeai-oss/knn-router/internal/store"
+	"github.com/spf13/cobra"
+)
+
+type evalOpts struct {
+	pointsDataPath string
+	scoresDataPath string
+	topK           int
+	weighting      string
+	temperature    float32
+	decay          float32
+	exponent       float32
+	format         string
+}
+
+var opts evalOpts
+
+var EvalCmd = &cobra.Command{
+	Use:   "eval",
+	Short: "Evaluate leave-one-out routing quality on a dataset",
+	Run: func(cmd *cobra.Command, args []string) {
+		if opts.topK < 1 {
+			log.Fatalf("--top-k must be at least 1")
+		}
+		weighting := server.Weighting{
+			Kernel:      server.WeightingKernel(opts.weighting),
+			Temperature: opts.temperature,
+			Decay:       opts.decay,
+			Exponent:    opts.exponent,
+		}.Override(nil)
+		if err := weighting.Validate(); err != nil {
+			log.Fatalf("invalid weighting: %v", err)
+		}
+
+		ldr := loader.NewLoader(store.PointsCollection, store.PointsCollection)
+		if err := ldr.LoadPoints(opts.pointsDataPath); err != nil {
+			log.Fatalf("failed to load points: %v", err)
+		}
+		if err := ldr.LoadScores(opts.scoresDataPath); err != nil {
+			log.Fatalf("failed to load scores: %v", err)
+		}
+
+		report, err := eval.Evaluate(
+			context.Background(),
+			ldr.Points(),
+			ldr.Vectors(),
+			opts.topK,
+			weighting,
+		)
+		if err != nil {
+			log.Fatalf("failed to evaluate: %v", err)
+		}
+
+		switch opts.format {
+		case "text":
+			err = report.WriteText(os.Stdout)
+		case "json":
+			err = report.WriteJSON(os.Stdout)
+		default:
+			log.Fatalf("unsupported output format: %s", opts.format)
+		}
+		if err != nil {
+			log.Fatalf("failed to write report: %v", err)
+		}
+	},
+}
+
+func init() {
+	EvalCmd.Flags().
+		StringVar(&opts.pointsDataPath, "points-data-path", "", "Path to JSONL-formatted dataset containing points")
+	EvalCmd.Flags().
+		StringVar(&opts.scoresDataPath, "scores-data-path", "", "Path to JSONL-formatted dataset containing target scores")
+	EvalCmd.Flags().
+		IntVarP(&opts.topK, "top-k", "k", 10, "The number of top hits to aggregate")
+	EvalCmd.Flags().
+		StringVar(&opts.weighting, "weighting", "similarity", "The kernel used to weight neighbors (similarity, softmax, rank, uniform, power)")
+	EvalCmd.Flags().
+		Float32Var(&opts.temperature, "weighting-temperature", 0.05, "The temperature of the softmax weighting kernel")
+	EvalCmd.Flags().
+		Float32Var(&opts.decay, "weighting-decay", 0.8, "The per-rank decay of the rank weighting kernel")
+	EvalCmd.Flags().
+		Float32Var(&opts.exponent, "weighting-exponent", 4, "The exponent of the power weighting kernel")
+	EvalCmd.Flags().
+		StringVar(&opts.format, "format", "text", "The output format of the report (text, json)")
+}
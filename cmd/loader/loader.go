@@ -1,16 +1,40 @@
 package loader
 
 import (
+	"context"
 	"log"
+	"net/http"
 
 	"github.com/pulzeai-oss/knn-router/internal/loader"
+	"github.com/pulzeai-oss/knn-router/internal/server"
+	"github.com/pulzeai-oss/knn-router/internal/store"
+	qdrant "github.com/qdrant/go-client/qdrant"
 	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
 )
 
 type loaderOpts struct {
-	DBPath         string
-	pointsDataPath string
-	scoresDataPath string
+	DBPath           string
+	collection       string
+	qdrantCollection string
+	pointsDataPath   string
+	scoresDataPath   string
+	localIndex       bool
+	qdrantAddr       string
+	qdrantTLS        bool
+	qdrantCAFile     string
+	qdrantCertFile   string
+	qdrantKeyFile    string
+	qdrantServerName string
+	qdrantAPIKey     string
+	qdrantHTTPURL    string
+	distanceMetric   string
+	recreate         bool
+	batchSize        int
+	maxRetries       int
+	payloadFields    []string
+	snapshot         bool
+	outputDir        string
 }
 
 var opts loaderOpts
@@ -19,16 +43,85 @@ var LoaderCmd = &cobra.Command{
 	Use:   "load",
 	Short: "Write dataset to database",
 	Run: func(cmd *cobra.Command, args []string) {
-		loader := loader.NewLoader()
-		if err := loader.LoadPoints(opts.pointsDataPath); err != nil {
+		if opts.qdrantAddr != "" && opts.batchSize < 1 {
+			log.Fatalf("--upsert-batch-size must be at least 1")
+		}
+
+		qdrantCollection := opts.qdrantCollection
+		if qdrantCollection == "" {
+			qdrantCollection = opts.collection
+		}
+		ldr := loader.NewLoader(opts.collection, qdrantCollection)
+		if err := ldr.LoadPoints(opts.pointsDataPath); err != nil {
 			log.Fatalf("failed to load points: %v", err)
 		}
-		if err := loader.LoadScores(opts.scoresDataPath); err != nil {
+		if err := ldr.LoadScores(opts.scoresDataPath); err != nil {
 			log.Fatalf("failed to load scores: %v", err)
 		}
-		if err := loader.SaveScores(opts.DBPath); err != nil {
+		if err := ldr.SaveScores(opts.DBPath); err != nil {
 			log.Fatalf("failed to write to DB: %v", err)
 		}
+		if opts.localIndex {
+			if err := ldr.SaveVectors(opts.DBPath); err != nil {
+				log.Fatalf("failed to write vectors to DB: %v", err)
+			}
+		}
+		if opts.qdrantAddr == "" {
+			return
+		}
+
+		distance, ok := qdrant.Distance_value[opts.distanceMetric]
+		if !ok || qdrant.Distance(distance) == qdrant.Distance_UnknownDistance {
+			log.Fatalf("unsupported distance metric: %s", opts.distanceMetric)
+		}
+		transport := server.TransportOpts{
+			TLS:          opts.qdrantTLS,
+			CAFile:       opts.qdrantCAFile,
+			CertFile:     opts.qdrantCertFile,
+			KeyFile:      opts.qdrantKeyFile,
+			ServerName:   opts.qdrantServerName,
+			APIKey:       opts.qdrantAPIKey,
+			APIKeyHeader: store.QdrantAPIKeyHeader,
+		}
+		transportOpts, err := server.TransportDialOptions(transport)
+		if err != nil {
+			log.Fatalf("invalid Qdrant transport options: %v", err)
+		}
+		qdrantConn, err := grpc.DialContext(context.Background(), opts.qdrantAddr, transportOpts...)
+		if err != nil {
+			log.Fatalf("failed to create connection to Qdrant server: %v", err)
+		}
+		defer qdrantConn.Close()
+
+		err = ldr.SaveCollection(context.Background(), qdrantConn, loader.QdrantOpts{
+			Distance:      qdrant.Distance(distance),
+			Recreate:      opts.recreate,
+			BatchSize:     opts.batchSize,
+			MaxRetries:    opts.maxRetries,
+			PayloadFields: opts.payloadFields,
+		})
+		if err != nil {
+			log.Fatalf("failed to write to Qdrant: %v", err)
+		}
+		if opts.snapshot {
+			// The HTTP API is secured like the gRPC API
+			tlsConfig, _ := transport.TLSConfig()
+			httpTransport := http.DefaultTransport.(*http.Transport).Clone()
+			if tlsConfig != nil {
+				httpTransport.TLSClientConfig = tlsConfig
+			}
+			err := ldr.SaveSnapshot(
+				context.Background(),
+				qdrantConn,
+				&http.Client{Transport: httpTransport},
+				opts.qdrantHTTPURL,
+				opts.qdrantAPIKey,
+				opts.outputDir,
+			)
+			if err != nil {
+				log.Fatalf("failed to save snapshot: %v", err)
+			}
+		}
 	},
 }
 
@@ -39,4 +132,40 @@ func init() {
 		StringVar(&opts.scoresDataPath, "scores-data-path", "", "Path to JSONL-formatted dataset containing target scores")
 	LoaderCmd.Flags().
 		StringVar(&opts.DBPath, "db-path", "scores.db", "The path to write Bolt database to")
+	LoaderCmd.Flags().
+		StringVar(&opts.collection, "collection", store.PointsCollection, "The name of the Bolt bucket and the Qdrant collection to write points to")
+	LoaderCmd.Flags().
+		StringVar(&opts.qdrantCollection, "qdrant-collection", "", "The name of the Qdrant collection to write points to, which the database records for the server to search (default --collection)")
+	LoaderCmd.Flags().
+		BoolVar(&opts.localIndex, "local-index", false, "Also write point embeddings to the database, for use with --index=local")
+	LoaderCmd.Flags().
+		StringVar(&opts.qdrantAddr, "qdrant-address", "", "Address and port of the Qdrant gRPC API to write point embeddings to (optional)")
+	LoaderCmd.Flags().
+		BoolVar(&opts.qdrantTLS, "qdrant-tls", false, "Connect to Qdrant over TLS, which is implied by the other --qdrant-tls-* flags")
+	LoaderCmd.Flags().
+		StringVar(&opts.qdrantCAFile, "qdrant-tls-ca-file", "", "Path to a PEM bundle of the CAs that sign Qdrant's certificate, instead of the system roots")
+	LoaderCmd.Flags().
+		StringVar(&opts.qdrantCertFile, "qdrant-tls-cert-file", "", "Path to the PEM client certificate for mTLS to Qdrant")
+	LoaderCmd.Flags().
+		StringVar(&opts.qdrantKeyFile, "qdrant-tls-key-file", "", "Path to the PEM client key for mTLS to Qdrant")
+	LoaderCmd.Flags().
+		StringVar(&opts.qdrantServerName, "qdrant-tls-server-name", "", "The server name to verify Qdrant's certificate against, if not the host of its address")
+	LoaderCmd.Flags().
+		StringVar(&opts.qdrantAPIKey, "qdrant-api-key", "", "The API key for Qdrant, sent as api-key metadata to the gRPC API and as the api-key header to the HTTP API")
+	LoaderCmd.Flags().
+		StringVar(&opts.qdrantHTTPURL, "qdrant-http-url", "http://localhost:6333", "Base URL of the Qdrant HTTP API, for downloading snapshots")
+	LoaderCmd.Flags().
+		StringVar(&opts.distanceMetric, "distance-metric", "Cosine", "The distance metric of the Qdrant collection (Cosine, Euclid, Dot, Manhattan)")
+	LoaderCmd.Flags().
+		BoolVar(&opts.recreate, "recreate-collection", false, "Delete the Qdrant collection before creating it, if it exists")
+	LoaderCmd.Flags().
+		IntVar(&opts.batchSize, "upsert-batch-size", 500, "The number of points per Qdrant upsert request")
+	LoaderCmd.Flags().
+		IntVar(&opts.maxRetries, "max-retries", 3, "The number of times to retry a failed Qdrant upsert request")
+	LoaderCmd.Flags().
+		StringSliceVar(&opts.payloadFields, "payload-fields", nil, "Extra fields of the points dataset to write to Qdrant payloads and index, besides category")
+	LoaderCmd.Flags().
+		BoolVar(&opts.snapshot, "snapshot", false, "Create a snapshot of the Qdrant collection, and download it into --output-dir")
+	LoaderCmd.Flags().
+		StringVar(&opts.outputDir, "output-dir", ".", "The directory to download the Qdrant snapshot to")
 }
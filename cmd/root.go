@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"github.com/pulzeai-oss/knn-router/cmd/eval"
 	"github.com/pulzeai-oss/knn-router/cmd/loader"
 	"github.com/pulzeai-oss/knn-router/cmd/server"
 	"github.com/spf13/cobra"
@@ -14,6 +15,7 @@ var rootCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(server.ServerCmd)
 	rootCmd.AddCommand(loader.LoaderCmd)
+	rootCmd.AddCommand(eval.EvalCmd)
 }
 
 func Execute() error {
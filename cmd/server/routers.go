@@ -0,0 +1,171 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+
+	"github.com/pulzeai-oss/knn-router/internal/server"
+	"google.golang.org/grpc"
+)
+
+// embedConfig configures an embedding backend.
+type embedConfig struct {
+	Backend string `json:"backend"`
+	Address string `json:"address"`
+	URL     string `json:"url"`
+	Model   string `json:"model"`
+	APIKey  string `json:"api_key"`
+}
+
+// routerConfig configures a named router. Unset fields default to the flags
+// of the default router, except for the collection, which defaults to the
+// name of the router.
+type routerConfig struct {
+	DBPath     string       `json:"db_path"`
+	Collection string       `json:"collection"`
+	Index      string       `json:"index"`
+	TopK       int          `json:"top_k"`
+	Embed      *embedConfig `json:"embed"`
+}
+
+// routersConfig is the file given with --routers-config.
+type routersConfig struct {
+	Routers map[string]routerConfig `json:"routers"`
+}
+
+func loadRoutersConfig(path string) (*routersConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config routersConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	for name, rc := range config.Routers {
+		if name == "" {
+			return nil, fmt.Errorf("router name is required")
+		}
+		if rc.DBPath == "" {
+			return nil, fmt.Errorf("db_path of router %s is required", name)
+		}
+		if rc.Collection == "" {
+			rc.Collection = name
+		}
+		if rc.Index == "" {
+			rc.Index = opts.index
+		}
+		if rc.TopK == 0 {
+			rc.TopK = opts.topK
+		}
+		if rc.Embed != nil {
+			if rc.Embed.Backend == "" {
+				rc.Embed.Backend = opts.embedBackend
+			}
+			if rc.Embed.Address == "" {
+				rc.Embed.Address = opts.embedAddr
+			}
+			if rc.Embed.URL == "" {
+				rc.Embed.URL = opts.embedURL
+			}
+			if rc.Embed.Model == "" {
+				rc.Embed.Model = opts.embedModel
+			}
+			if rc.Embed.APIKey == "" {
+				rc.Embed.APIKey = opts.embedAPIKey
+			}
+		}
+		config.Routers[name] = rc
+	}
+	return &config, nil
+}
+
+// names returns the names of the routers, sorted.
+func (c *routersConfig) names() []string {
+	names := make([]string, 0, len(c.Routers))
+	for name := range c.Routers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// newEmbedder connects to an embedding backend, wrapped with micro-batching
+// and caching if enabled. The returned func closes the connection.
+func newEmbedder(upstream string, config embedConfig, policy server.UpstreamPolicy) (server.Embedder, func()) {
+	transport := server.TransportOpts{
+		TLS:        opts.embedTLS,
+		CAFile:     opts.embedCAFile,
+		CertFile:   opts.embedCertFile,
+		KeyFile:    opts.embedKeyFile,
+		ServerName: opts.embedServerName,
+	}
+	var embedder server.Embedder
+	closeEmbedder := func() {}
+	switch config.Backend {
+	case "tei":
+		// TEI expects the API key as a bearer token
+		if config.APIKey != "" {
+			transport.APIKey = "Bearer " + config.APIKey
+			transport.APIKeyHeader = "authorization"
+		}
+		transportOpts, err := server.TransportDialOptions(transport)
+		if err != nil {
+			log.Fatalf("invalid embedding server transport options: %v", err)
+		}
+		embedConn, err := grpc.DialContext(
+			context.Background(),
+			config.Address,
+			append(server.UpstreamDialOptions(upstream, policy), transportOpts...)...,
+		)
+		if err != nil {
+			log.Fatalf("failed to create connection to embedding server: %v", err)
+		}
+		closeEmbedder = func() { embedConn.Close() }
+
+		embedder, err = server.NewTEIEmbedder(context.Background(), embedConn)
+		if err != nil {
+			log.Fatalf("failed to get info from embedding server: %v", err)
+		}
+	case "openai":
+		if opts.embedMaxInputLength < 1 {
+			log.Fatalf("--embed-max-input-length must be at least 1")
+		}
+		tlsConfig, err := transport.TLSConfig()
+		if err != nil {
+			log.Fatalf("invalid embedding server transport options: %v", err)
+		}
+		embedder = server.NewOpenAIEmbedder(
+			config.URL,
+			config.Model,
+			config.APIKey,
+			opts.embedMaxInputLength,
+			opts.embedMaxBatchSize,
+			tlsConfig,
+		)
+	default:
+		log.Fatalf("unsupported embedding backend: %s", config.Backend)
+	}
+
+	if opts.embedBatchWindow > 0 {
+		// Batches may not exceed the embedding server's maximum batch size
+		maxBatchSize := max(embedder.Info().MaxBatchSize, 1)
+		if opts.embedBatchMaxSize > 0 {
+			maxBatchSize = min(maxBatchSize, opts.embedBatchMaxSize)
+		}
+		embedder = server.NewCoalescingEmbedder(embedder, opts.embedBatchWindow, maxBatchSize)
+	}
+	if opts.embedCacheSize > 0 {
+		embedder = server.NewCachedEmbedder(
+			embedder,
+			opts.embedCacheTTL,
+			opts.embedCacheSize,
+			opts.embedCacheMaxBytes,
+		)
+	}
+	return embedder, closeEmbedder
+}
@@ -3,21 +3,81 @@ package server
 import (
 	"context"
 	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/pulzeai-oss/knn-router/internal/server"
-	"github.com/pulzeai-oss/knn-router/internal/teipb"
+	"github.com/pulzeai-oss/knn-router/internal/store"
 	"github.com/spf13/cobra"
 	bolt "go.etcd.io/bbolt"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 type serverOpts struct {
-	bindAddr   string
-	embedAddr  string
-	qdrantAddr string
-	DBPath     string
-	topK       int
+	bindAddr            string
+	grpcBindAddr        string
+	embedBackend        string
+	embedAddr           string
+	embedURL            string
+	embedModel          string
+	embedAPIKey         string
+	embedTLS            bool
+	embedCAFile         string
+	embedCertFile       string
+	embedKeyFile        string
+	embedServerName     string
+	embedMaxInputLength int
+	embedMaxBatchSize   int
+	embedBatchWindow    time.Duration
+	embedBatchMaxSize   int
+	embedCacheSize      int
+	embedCacheMaxBytes  int
+	embedCacheTTL       time.Duration
+	index               string
+	qdrantAddr          string
+	qdrantTLS           bool
+	qdrantCAFile        string
+	qdrantCertFile      string
+	qdrantKeyFile       string
+	qdrantServerName    string
+	qdrantAPIKey        string
+	DBPath              string
+	collection          string
+	routersConfig       string
+	authKeysFile        string
+	adminKey            string
+	topK                int
+	maxTopK             int
+	weighting           string
+	temperature         float32
+	decay               float32
+	exponent            float32
+	turnStrategy        string
+	turns               int
+	minSimilarity       float32
+	minNeighbors        int
+	fallbackScores      map[string]string
+	alternates          int
+	upstreamURLs        map[string]string
+	upstreamModels      map[string]string
+	upstreamAPIKeys     map[string]string
+	reloadInterval      time.Duration
+	readTimeout         time.Duration
+	writeTimeout        time.Duration
+	idleTimeout         time.Duration
+	shutdownDelay       time.Duration
+	gracePeriod         time.Duration
+	preloadScores       bool
+	tokenizeTimeout     time.Duration
+	embedTimeout        time.Duration
+	searchTimeout       time.Duration
+	maxRetries          int
+	retryBackoff        time.Duration
+	breakerFailures     int
+	breakerCooldown     time.Duration
 }
 
 var opts serverOpts
@@ -26,60 +86,387 @@ var ServerCmd = &cobra.Command{
 	Use:   "server",
 	Short: "Start the KNN-router server",
 	Run: func(cmd *cobra.Command, args []string) {
-		DB, err := bolt.Open(opts.DBPath, 0600, &bolt.Options{ReadOnly: true})
-		if err != nil {
-			log.Fatalf("failed to open scores database: %v", err)
+		weighting := server.Weighting{
+			Kernel:      server.WeightingKernel(opts.weighting),
+			Temperature: opts.temperature,
+			Decay:       opts.decay,
+			Exponent:    opts.exponent,
+		}
+		if err := weighting.Validate(); err != nil {
+			log.Fatalf("invalid weighting: %v", err)
 		}
-		defer DB.Close()
 
-		embedConn, err := grpc.DialContext(
-			context.Background(),
-			opts.embedAddr,
-			grpc.WithTransportCredentials(insecure.NewCredentials()),
-		)
-		if err != nil {
-			log.Fatalf("failed to create connection to embedding server: %v", err)
+		turnSelection := server.TurnSelection{
+			Strategy: server.TurnStrategy(opts.turnStrategy),
+			Turns:    opts.turns,
+		}
+		if err := turnSelection.Validate(); err != nil {
+			log.Fatalf("invalid turn selection: %v", err)
 		}
-		defer embedConn.Close()
 
-		infoClient := teipb.NewInfoClient(embedConn)
-		infoResp, err := infoClient.Info(context.Background(), &teipb.InfoRequest{})
-		if err != nil {
-			log.Fatalf("failed to get info from embedding server: %v", err)
+		if opts.topK < 1 || opts.topK > opts.maxTopK {
+			log.Fatalf("--top-k must be between 1 and --max-top-k (%d)", opts.maxTopK)
 		}
 
-		qdrantConn, err := grpc.DialContext(
-			context.Background(),
-			opts.qdrantAddr,
-			grpc.WithTransportCredentials(insecure.NewCredentials()),
-		)
-		if err != nil {
-			log.Fatalf("failed to create connection to Qdrant server: %v", err)
+		if opts.alternates < 0 {
+			log.Fatalf("--alternates must not be negative")
+		}
+
+		var fallback []server.Score
+		for target, score := range opts.fallbackScores {
+			v, err := strconv.ParseFloat(score, 32)
+			if err != nil {
+				log.Fatalf("invalid fallback score for target %s: %v", target, err)
+			}
+			fallback = append(fallback, server.Score{Target: target, Score: float32(v)})
 		}
-		defer qdrantConn.Close()
+		server.SortScores(fallback)
 
-		svr := server.NewServer(
-			embedConn,
-			qdrantConn,
-			DB,
+		upstreams := make(map[string]server.Upstream)
+		for target, baseURL := range opts.upstreamURLs {
+			model := opts.upstreamModels[target]
+			if model == "" {
+				model = target
+			}
+			upstreams[target] = server.Upstream{
+				BaseURL: baseURL,
+				Model:   model,
+				APIKey:  opts.upstreamAPIKeys[target],
+			}
+		}
+		for _, targets := range []map[string]string{opts.upstreamModels, opts.upstreamAPIKeys} {
+			for target := range targets {
+				if _, ok := upstreams[target]; !ok {
+					log.Fatalf("no upstream URL for target %s", target)
+				}
+			}
+		}
+
+		policy := server.UpstreamPolicy{
+			MaxRetries:      opts.maxRetries,
+			RetryBackoff:    opts.retryBackoff,
+			BreakerFailures: opts.breakerFailures,
+			BreakerCooldown: opts.breakerCooldown,
+		}
+
+		embedder, closeEmbedder := newEmbedder("tei", embedConfig{
+			Backend: opts.embedBackend,
+			Address: opts.embedAddr,
+			URL:     opts.embedURL,
+			Model:   opts.embedModel,
+			APIKey:  opts.embedAPIKey,
+		}, policy)
+		defer closeEmbedder()
+
+		// The Qdrant connection is shared by every router that uses it
+		var qdrantConn *grpc.ClientConn
+		newIndex := func(index string) server.IndexFactory {
+			switch index {
+			case "qdrant":
+				if qdrantConn == nil {
+					transportOpts, err := server.TransportDialOptions(server.TransportOpts{
+						TLS:          opts.qdrantTLS,
+						CAFile:       opts.qdrantCAFile,
+						CertFile:     opts.qdrantCertFile,
+						KeyFile:      opts.qdrantKeyFile,
+						ServerName:   opts.qdrantServerName,
+						APIKey:       opts.qdrantAPIKey,
+						APIKeyHeader: store.QdrantAPIKeyHeader,
+					})
+					if err != nil {
+						log.Fatalf("invalid Qdrant transport options: %v", err)
+					}
+					qdrantConn, err = grpc.DialContext(
+						context.Background(),
+						opts.qdrantAddr,
+						append(server.UpstreamDialOptions("qdrant", policy), transportOpts...)...,
+					)
+					if err != nil {
+						log.Fatalf("failed to create connection to Qdrant server: %v", err)
+					}
+				}
+				return func(_ *bolt.DB, collection string) (server.VectorIndex, error) {
+					return server.NewQdrantIndex(qdrantConn, collection), nil
+				}
+			case "local":
+				return func(DB *bolt.DB, collection string) (server.VectorIndex, error) {
+					return server.NewLocalIndex(DB, collection)
+				}
+			}
+			log.Fatalf("unsupported vector index: %s", index)
+			return nil
+		}
+		defer func() {
+			if qdrantConn != nil {
+				qdrantConn.Close()
+			}
+		}()
+		timeouts := server.Timeouts{
+			Tokenize: opts.tokenizeTimeout,
+			Embed:    opts.embedTimeout,
+			Search:   opts.searchTimeout,
+		}
+
+		svr, err := server.NewServer(
+			embedder,
+			newIndex(opts.index),
+			opts.DBPath,
+			opts.collection,
 			opts.topK,
-			int(infoResp.MaxInputLength),
+			opts.maxTopK,
+			weighting,
+			turnSelection,
+			opts.minSimilarity,
+			opts.minNeighbors,
+			fallback,
+			opts.alternates,
+			upstreams,
+			timeouts,
+			opts.preloadScores,
 		)
-		if err := svr.ListenAndServe(opts.bindAddr); err != nil {
+		if err != nil {
+			log.Fatalf("failed to load scores database: %v", err)
+		}
+		routers := []*server.Server{svr}
+
+		if opts.routersConfig != "" {
+			config, err := loadRoutersConfig(opts.routersConfig)
+			if err != nil {
+				log.Fatalf("failed to load routers config: %v", err)
+			}
+			for _, name := range config.names() {
+				rc := config.Routers[name]
+				if rc.TopK < 1 || rc.TopK > opts.maxTopK {
+					log.Fatalf("top_k of router %s must be between 1 and --max-top-k (%d)", name, opts.maxTopK)
+				}
+				routerEmbedder := embedder
+				if rc.Embed != nil {
+					var closeRouterEmbedder func()
+					routerEmbedder, closeRouterEmbedder = newEmbedder("tei-"+name, *rc.Embed, policy)
+					defer closeRouterEmbedder()
+				}
+				router, err := server.NewServer(
+					routerEmbedder,
+					newIndex(rc.Index),
+					rc.DBPath,
+					rc.Collection,
+					rc.TopK,
+					opts.maxTopK,
+					weighting,
+					turnSelection,
+					opts.minSimilarity,
+					opts.minNeighbors,
+					fallback,
+					opts.alternates,
+					nil,
+					timeouts,
+					opts.preloadScores,
+				)
+				if err != nil {
+					log.Fatalf("failed to load scores database of router %s: %v", name, err)
+				}
+				svr.AddRouter(name, router)
+				routers = append(routers, router)
+			}
+		}
+
+		var auth *server.Authenticator
+		if opts.authKeysFile != "" {
+			auth, err = server.NewAuthenticator(opts.authKeysFile)
+			if err != nil {
+				log.Fatalf("failed to load keys file: %v", err)
+			}
+			svr.RequireAuth(auth)
+		}
+		if opts.adminKey != "" {
+			svr.EnableAdmin(opts.adminKey)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		if opts.reloadInterval > 0 {
+			for _, router := range routers {
+				go router.WatchDB(ctx, opts.reloadInterval)
+			}
+			if auth != nil {
+				go auth.Watch(ctx, opts.reloadInterval)
+			}
+		}
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		defer signal.Stop(hup)
+		go func() {
+			for range hup {
+				for _, router := range routers {
+					if err := router.Reload(ctx); err != nil {
+						log.Printf("failed to reload scores database: %v", err)
+					}
+				}
+				if auth != nil {
+					if err := auth.Reload(); err != nil {
+						log.Printf("failed to reload keys file: %v", err)
+					}
+				}
+			}
+		}()
+
+		errCh := make(chan error, 2)
+		go func() {
+			errCh <- svr.ListenAndServe(
+				opts.bindAddr,
+				opts.readTimeout,
+				opts.writeTimeout,
+				opts.idleTimeout,
+			)
+		}()
+		go func() { errCh <- svr.ServeGRPC(opts.grpcBindAddr) }()
+
+		term := make(chan os.Signal, 1)
+		signal.Notify(term, syscall.SIGTERM, os.Interrupt)
+		select {
+		case err := <-errCh:
 			log.Fatalf("failed to start server: %v", err)
+		case sig := <-term:
+			log.Printf("received %v, shutting down", sig)
+		}
+
+		// Drain in-flight requests and close the scores databases, after which
+		// the deferred calls close the upstream connections
+		shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), opts.gracePeriod)
+		defer cancelShutdown()
+		if err := svr.Shutdown(shutdownCtx, opts.shutdownDelay); err != nil {
+			log.Printf("failed to drain in-flight requests: %v", err)
 		}
+		for _, router := range routers {
+			if err := router.Close(shutdownCtx); err != nil {
+				log.Printf("failed to close scores database: %v", err)
+			}
+		}
+		log.Printf("server stopped")
 	},
 }
 
 func init() {
 	ServerCmd.Flags().
 		StringVarP(&opts.bindAddr, "bind-addr", "a", ":8888", "Address and port to bind the server to")
+	ServerCmd.Flags().
+		StringVarP(&opts.grpcBindAddr, "grpc-bind-addr", "g", ":8890", "Address and port to bind the gRPC server to")
+	ServerCmd.Flags().
+		StringVar(&opts.embedBackend, "embed-backend", "tei", "The embedding backend to use (tei, openai)")
 	ServerCmd.Flags().
 		StringVarP(&opts.embedAddr, "embed-address", "e", "localhost:8889", "Address and port of the embedding inference server")
+	ServerCmd.Flags().
+		StringVar(&opts.embedURL, "embed-url", "http://localhost:8080/v1", "Base URL of the OpenAI-compatible embeddings API")
+	ServerCmd.Flags().
+		StringVar(&opts.embedModel, "embed-model", "", "The model to request from the OpenAI-compatible embeddings API")
+	ServerCmd.Flags().
+		StringVar(&opts.embedAPIKey, "embed-api-key", "", "The API key for the embedding server, sent as a bearer token")
+	ServerCmd.Flags().
+		BoolVar(&opts.embedTLS, "embed-tls", false, "Connect to the embedding server over TLS, which is implied by the other --embed-tls-* flags")
+	ServerCmd.Flags().
+		StringVar(&opts.embedCAFile, "embed-tls-ca-file", "", "Path to a PEM bundle of the CAs that sign the embedding server's certificate, instead of the system roots")
+	ServerCmd.Flags().
+		StringVar(&opts.embedCertFile, "embed-tls-cert-file", "", "Path to the PEM client certificate for mTLS to the embedding server")
+	ServerCmd.Flags().
+		StringVar(&opts.embedKeyFile, "embed-tls-key-file", "", "Path to the PEM client key for mTLS to the embedding server")
+	ServerCmd.Flags().
+		StringVar(&opts.embedServerName, "embed-tls-server-name", "", "The server name to verify the embedding server's certificate against, if not the host of its address")
+	ServerCmd.Flags().
+		IntVar(&opts.embedMaxInputLength, "embed-max-input-length", 512, "The maximum number of tokens accepted by the OpenAI-compatible embeddings API")
+	ServerCmd.Flags().
+		IntVar(&opts.embedMaxBatchSize, "embed-max-batch-size", 32, "The maximum number of inputs per OpenAI-compatible embeddings request")
+	ServerCmd.Flags().
+		DurationVar(&opts.embedBatchWindow, "embed-batch-window", 0, "How long to collect concurrent embedding calls into a single batch, e.g. 2ms, or 0 to disable batching")
+	ServerCmd.Flags().
+		IntVar(&opts.embedBatchMaxSize, "embed-batch-max-size", 0, "The maximum number of concurrent embedding calls per batch, up to the embedding server's maximum batch size")
+	ServerCmd.Flags().
+		IntVar(&opts.embedCacheSize, "embed-cache-size", 0, "The maximum number of tokenizations and embeddings to cache, or 0 to disable the cache")
+	ServerCmd.Flags().
+		IntVar(&opts.embedCacheMaxBytes, "embed-cache-max-bytes", 64<<20, "The approximate maximum size of the embedding cache in bytes, or 0 for no limit")
+	ServerCmd.Flags().
+		DurationVar(&opts.embedCacheTTL, "embed-cache-ttl", time.Hour, "How long cached tokenizations and embeddings are kept")
+	ServerCmd.Flags().
+		StringVar(&opts.index, "index", "qdrant", "The vector index to search (qdrant, local)")
 	ServerCmd.Flags().
 		StringVarP(&opts.qdrantAddr, "qdrant-address", "q", "localhost:6334", "Address and port of the Qdrant server")
+	ServerCmd.Flags().
+		BoolVar(&opts.qdrantTLS, "qdrant-tls", false, "Connect to Qdrant over TLS, which is implied by the other --qdrant-tls-* flags")
+	ServerCmd.Flags().
+		StringVar(&opts.qdrantCAFile, "qdrant-tls-ca-file", "", "Path to a PEM bundle of the CAs that sign Qdrant's certificate, instead of the system roots")
+	ServerCmd.Flags().
+		StringVar(&opts.qdrantCertFile, "qdrant-tls-cert-file", "", "Path to the PEM client certificate for mTLS to Qdrant")
+	ServerCmd.Flags().
+		StringVar(&opts.qdrantKeyFile, "qdrant-tls-key-file", "", "Path to the PEM client key for mTLS to Qdrant")
+	ServerCmd.Flags().
+		StringVar(&opts.qdrantServerName, "qdrant-tls-server-name", "", "The server name to verify Qdrant's certificate against, if not the host of its address")
+	ServerCmd.Flags().
+		StringVar(&opts.qdrantAPIKey, "qdrant-api-key", "", "The API key for Qdrant, sent as api-key metadata")
 	ServerCmd.Flags().
 		StringVarP(&opts.DBPath, "db-path", "s", "scores.db", "The path to the Bolt database")
+	ServerCmd.Flags().
+		StringVar(&opts.collection, "collection", store.PointsCollection, "The name of the Bolt bucket of the default router, and of its Qdrant collection unless the scores database records another")
+	ServerCmd.Flags().
+		StringVar(&opts.routersConfig, "routers-config", "", "Path to a JSON file of named routers to serve at /v1/routers/{name}/route, alongside the default router (optional)")
+	ServerCmd.Flags().
+		StringVar(&opts.authKeysFile, "auth-keys-file", "", "Path to a JSON file of API keys that routing requests must present as bearer tokens, with their rate limits and default request options (optional)")
+	ServerCmd.Flags().
+		StringVar(&opts.adminKey, "admin-key", "", "The bearer token required by the admin endpoints, such as /admin/reload, which are only served if it is set")
 	ServerCmd.Flags().
 		IntVarP(&opts.topK, "top-k", "k", 10, "The number of top hits to aggregate")
+	ServerCmd.Flags().
+		IntVar(&opts.maxTopK, "max-top-k", 100, "The largest number of top hits a request may ask to aggregate")
+	ServerCmd.Flags().
+		StringVar(&opts.weighting, "weighting", "similarity", "The kernel used to weight neighbors (similarity, softmax, rank, uniform, power)")
+	ServerCmd.Flags().
+		Float32Var(&opts.temperature, "weighting-temperature", 0.05, "The temperature of the softmax weighting kernel")
+	ServerCmd.Flags().
+		Float32Var(&opts.decay, "weighting-decay", 0.8, "The per-rank decay of the rank weighting kernel")
+	ServerCmd.Flags().
+		Float32Var(&opts.exponent, "weighting-exponent", 4, "The exponent of the power weighting kernel")
+	ServerCmd.Flags().
+		StringVar(&opts.turnStrategy, "turn-strategy", "last_user", "The turns of a conversation to embed (last_user, last_n, system_last_user, per_turn)")
+	ServerCmd.Flags().
+		IntVar(&opts.turns, "turns", 3, "The number of turns embedded by the last_n and per_turn strategies")
+	ServerCmd.Flags().
+		Float32Var(&opts.minSimilarity, "min-similarity", -1, "Neighbors less similar to the query than this are ignored")
+	ServerCmd.Flags().
+		IntVar(&opts.minNeighbors, "min-neighbors", 1, "The number of neighbors above --min-similarity below which a query is out of distribution")
+	ServerCmd.Flags().
+		StringToStringVar(&opts.fallbackScores, "fallback-scores", nil, "The target scores to return for out of distribution queries, e.g. chitchat-agent=1")
+	ServerCmd.Flags().
+		IntVar(&opts.alternates, "alternates", 2, "The number of runner-up targets to report in the routing decision")
+	ServerCmd.Flags().
+		StringToStringVar(&opts.upstreamURLs, "upstream-urls", nil, "The base URL of the OpenAI-compatible API to proxy chat completions to for each target, e.g. politics-agent=http://localhost:9000/v1")
+	ServerCmd.Flags().
+		StringToStringVar(&opts.upstreamModels, "upstream-models", nil, "The model to request from the upstream of each target, if not the target name")
+	ServerCmd.Flags().
+		StringToStringVar(&opts.upstreamAPIKeys, "upstream-api-keys", nil, "The API key for the upstream of each target")
+	ServerCmd.Flags().
+		BoolVar(&opts.preloadScores, "preload-scores", false, "Decode the target scores of every point into memory when the Bolt database is loaded, rather than on each query")
+	ServerCmd.Flags().
+		DurationVar(&opts.reloadInterval, "reload-interval", 10*time.Second, "How often to check the Bolt database and the keys file for changes and reload them, or 0 to disable")
+	ServerCmd.Flags().
+		DurationVar(&opts.tokenizeTimeout, "tokenize-timeout", 2*time.Second, "The timeout of tokenization calls to the embedding server, or 0 for none")
+	ServerCmd.Flags().
+		DurationVar(&opts.embedTimeout, "embed-timeout", 5*time.Second, "The timeout of embedding calls to the embedding server, or 0 for none")
+	ServerCmd.Flags().
+		DurationVar(&opts.searchTimeout, "search-timeout", 2*time.Second, "The timeout of nearest neighbor searches, or 0 for none")
+	ServerCmd.Flags().
+		IntVar(&opts.maxRetries, "upstream-max-retries", 2, "The number of times a TEI or Qdrant call that failed with a retryable code is retried")
+	ServerCmd.Flags().
+		DurationVar(&opts.retryBackoff, "upstream-retry-backoff", 25*time.Millisecond, "The base of the exponential backoff, with jitter, between retries of TEI and Qdrant calls")
+	ServerCmd.Flags().
+		IntVar(&opts.breakerFailures, "breaker-failures", 5, "The number of consecutive failed calls to TEI or Qdrant after which calls to it fail fast, or 0 to disable the circuit breaker")
+	ServerCmd.Flags().
+		DurationVar(&opts.breakerCooldown, "breaker-cooldown", 10*time.Second, "How long calls to TEI or Qdrant fail fast before a trial call is let through")
+	ServerCmd.Flags().
+		DurationVar(&opts.readTimeout, "read-timeout", 10*time.Second, "The maximum duration for reading an HTTP request, including the body")
+	ServerCmd.Flags().
+		DurationVar(&opts.writeTimeout, "write-timeout", 2*time.Minute, "The maximum duration for writing an HTTP response, including streamed chat completions")
+	ServerCmd.Flags().
+		DurationVar(&opts.idleTimeout, "idle-timeout", 2*time.Minute, "How long idle HTTP keep-alive connections are kept open")
+	ServerCmd.Flags().
+		DurationVar(&opts.shutdownDelay, "shutdown-delay", 5*time.Second, "How long to keep serving after SIGTERM while reporting not ready, so that load balancers stop sending requests")
+	ServerCmd.Flags().
+		DurationVar(&opts.gracePeriod, "shutdown-grace-period", 25*time.Second, "The maximum duration of a shutdown, including --shutdown-delay, after which in-flight requests are cut off")
 }
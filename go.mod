@@ -3,6 +3,8 @@ module github.com/pulzeai-oss/knn-router
 go 1.22.0
 
 require (
+	github.com/prometheus/client_golang v1.19.0
+	github.com/pulzeai-oss/knn-router/internal/routerpb v0.0.0-00010101000000-000000000000
 	github.com/pulzeai-oss/knn-router/internal/scorespb v0.0.0-00010101000000-000000000000
 	github.com/pulzeai-oss/knn-router/internal/teipb v0.0.0-00010101000000-000000000000
 	github.com/qdrant/go-client v1.7.0
@@ -13,15 +15,22 @@ require (
 )
 
 require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
 	github.com/golang/protobuf v1.5.3 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
+	github.com/prometheus/client_model v0.5.0 // indirect
+	github.com/prometheus/common v0.48.0 // indirect
+	github.com/prometheus/procfs v0.12.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
-	golang.org/x/net v0.18.0 // indirect
-	golang.org/x/sys v0.14.0 // indirect
+	golang.org/x/net v0.20.0 // indirect
+	golang.org/x/sys v0.16.0 // indirect
 	golang.org/x/text v0.14.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20231106174013-bbf56f31fb17 // indirect
 )
 
+replace github.com/pulzeai-oss/knn-router/internal/routerpb => ./internal/routerpb
+
 replace github.com/pulzeai-oss/knn-router/internal/scorespb => ./internal/scorespb
 
 replace github.com/pulzeai-oss/knn-router/internal/teipb => ./internal/teipb
@@ -0,0 +1,171 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pulzeai-oss/knn-router/internal/scorespb"
+	"github.com/pulzeai-oss/knn-router/internal/server"
+)
+
+type CategoryReport struct {
+	Category      string  `json:"category"`
+	Points        int     `json:"points"`
+	Top1Agreement float64 `json:"top1_agreement"`
+	MeanRegret    float64 `json:"mean_regret"`
+}
+
+type Report struct {
+	TopK          int              `json:"top_k"`
+	Weighting     server.Weighting `json:"weighting"`
+	Points        int              `json:"points"`
+	Skipped       int              `json:"skipped"`
+	Top1Agreement float64          `json:"top1_agreement"`
+	MeanRegret    float64          `json:"mean_regret"`
+	Categories    []CategoryReport `json:"categories"`
+}
+
+// Evaluate routes every point using its stored embedding, while leaving the
+// point itself out of the index, and compares the chosen target with the
+// point's own target scores. A point agrees if the chosen target has its best
+// score, and its regret is the difference between its best score and that of
+// the chosen target. Points without target scores are skipped.
+func Evaluate(
+	ctx context.Context,
+	points map[string]*scorespb.Point,
+	vectors map[string][]float32,
+	topK int,
+	weighting server.Weighting,
+) (*Report, error) {
+	if topK < 1 {
+		return nil, fmt.Errorf("top-k must be at least 1")
+	}
+	idx, err := server.NewLocalIndexFromVectors(vectors)
+	if err != nil {
+		return nil, err
+	}
+	lookup := func(uid string) (*scorespb.Point, error) {
+		p, exists := points[uid]
+		if !exists {
+			return nil, fmt.Errorf("point UID '%s' not found", uid)
+		}
+		return p, nil
+	}
+
+	uids := make([]string, 0, len(points))
+	for uid := range points {
+		uids = append(uids, uid)
+	}
+	slices.Sort(uids)
+
+	report := Report{TopK: topK, Weighting: weighting}
+	categories := make(map[string]*CategoryReport)
+	for _, uid := range uids {
+		point := points[uid]
+		if len(point.GetScores()) == 0 {
+			report.Skipped++
+			continue
+		}
+		vector, exists := vectors[uid]
+		if !exists {
+			return nil, fmt.Errorf("embedding for point UID '%s' not found", uid)
+		}
+
+		// Search for one extra neighbor, to make up for leaving the point out
+		neighbors, err := idx.Search(ctx, vector, topK+1, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search neighbors of point UID '%s': %v", uid, err)
+		}
+		neighbors = slices.DeleteFunc(neighbors, func(neighbor server.Neighbor) bool {
+			return neighbor.ID == uid
+		})
+		neighbors = neighbors[:min(len(neighbors), topK)]
+		res, err := server.Aggregate(neighbors, weighting, lookup)
+		if err != nil {
+			return nil, err
+		}
+
+		truth := make(map[string]float32)
+		var oracle float32
+		for i, score := range point.GetScores() {
+			truth[score.GetTarget()] = score.GetScore()
+			if i == 0 || score.GetScore() > oracle {
+				oracle = score.GetScore()
+			}
+		}
+		var chosenScore float32
+		if chosen, ok := server.Best(res.Scores); ok {
+			chosenScore = truth[chosen.Target]
+		}
+		agreement := 0.0
+		if chosenScore == oracle {
+			agreement = 1
+		}
+		regret := float64(oracle - chosenScore)
+
+		c, exists := categories[point.GetCategory()]
+		if !exists {
+			c = &CategoryReport{Category: point.GetCategory()}
+			categories[point.GetCategory()] = c
+		}
+		c.Points++
+		c.Top1Agreement += agreement
+		c.MeanRegret += regret
+		report.Points++
+		report.Top1Agreement += agreement
+		report.MeanRegret += regret
+	}
+
+	// Turn the accumulated sums into means
+	if report.Points > 0 {
+		report.Top1Agreement /= float64(report.Points)
+		report.MeanRegret /= float64(report.Points)
+	}
+	for _, c := range categories {
+		c.Top1Agreement /= float64(c.Points)
+		c.MeanRegret /= float64(c.Points)
+		report.Categories = append(report.Categories, *c)
+	}
+	slices.SortFunc(report.Categories, func(a, b CategoryReport) int {
+		return strings.Compare(a.Category, b.Category)
+	})
+	return &report, nil
+}
+
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Points:\t%d (%d skipped)\n", r.Points, r.Skipped)
+	fmt.Fprintf(tw, "Top-K:\t%d\n", r.TopK)
+	fmt.Fprintf(tw, "Weighting:\t%s\n", r.Weighting.Kernel)
+	fmt.Fprintf(tw, "Top-1 agreement:\t%.2f%%\n", 100*r.Top1Agreement)
+	fmt.Fprintf(tw, "Mean regret:\t%.4f\n", r.MeanRegret)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CATEGORY\tPOINTS\tTOP-1 AGREEMENT\tMEAN REGRET")
+	for _, c := range r.Categories {
+		fmt.Fprintf(
+			tw,
+			"%s\t%d\t%.2f%%\t%.4f\n",
+			c.Category,
+			c.Points,
+			100*c.Top1Agreement,
+			c.MeanRegret,
+		)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,49 @@
+package eval
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pulzeai-oss/knn-router/internal/scorespb"
+	"github.com/pulzeai-oss/knn-router/internal/server"
+)
+
+func TestEvaluateTopK(t *testing.T) {
+	points := map[string]*scorespb.Point{
+		"a": {Category: "test", Scores: []*scorespb.Score{{Target: "x", Score: 1}, {Target: "y", Score: 0}}},
+		"b": {Category: "test", Scores: []*scorespb.Score{{Target: "x", Score: 1}, {Target: "y", Score: 0}}},
+		"c": {Category: "test", Scores: []*scorespb.Score{{Target: "x", Score: 0}, {Target: "y", Score: 1}}},
+	}
+	vectors := map[string][]float32{
+		"a": {1, 0},
+		"b": {0.9, 0.1},
+		"c": {0, 1},
+	}
+	weighting := server.Weighting{Kernel: server.UniformKernel}
+	for _, tt := range []struct {
+		name    string
+		topK    int
+		wantErr bool
+	}{
+		{name: "negative", topK: -1, wantErr: true},
+		{name: "zero", topK: 0, wantErr: true},
+		{name: "one", topK: 1},
+		{name: "more than the points", topK: 10},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := Evaluate(context.Background(), points, vectors, tt.topK, weighting)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Evaluate succeeded with top-k %d", tt.topK)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Evaluate failed: %v", err)
+			}
+			if report.Points != len(points) {
+				t.Errorf("report has %d points, want %d", report.Points, len(points))
+			}
+		})
+	}
+}
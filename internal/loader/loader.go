@@ -2,19 +2,26 @@ package loader
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
 
 	"github.com/pulzeai-oss/knn-router/internal/scorespb"
-	"github.com/pulzeai-oss/knn-router/internal/server"
+	"github.com/pulzeai-oss/knn-router/internal/store"
 	bolt "go.etcd.io/bbolt"
 	"google.golang.org/protobuf/proto"
 )
 
+const maxLineSize = 16 * 1024 * 1024
+
 type PointRow struct {
-	PointUID string `json:"point_uid"`
-	Category string `json:"category"`
+	PointUID  string    `json:"point_uid"`
+	Category  string    `json:"category"`
+	Embedding []float32 `json:"embedding"`
+	// Payload holds the category and any other fields of the row, for
+	// filtering searches in Qdrant.
+	Payload map[string]any `json:"-"`
 }
 
 type TargetScoreRow struct {
@@ -24,11 +31,34 @@ type TargetScoreRow struct {
 }
 
 type Loader struct {
-	points map[string]*scorespb.Point
+	// collection is the name of the Bolt bucket that the points are written
+	// to, and vectorCollection the name of the Qdrant collection.
+	collection       string
+	vectorCollection string
+	points           map[string]*scorespb.Point
+	vectors          map[string][]float32
+	payloads         map[string]map[string]any
+	dim              int
+}
+
+func NewLoader(collection, vectorCollection string) *Loader {
+	return &Loader{
+		collection:       collection,
+		vectorCollection: vectorCollection,
+		points:           make(map[string]*scorespb.Point),
+		vectors:          make(map[string][]float32),
+		payloads:         make(map[string]map[string]any),
+	}
+}
+
+// Points returns the loaded points, keyed by UID.
+func (l *Loader) Points() map[string]*scorespb.Point {
+	return l.points
 }
 
-func NewLoader() *Loader {
-	return &Loader{points: make(map[string]*scorespb.Point)}
+// Vectors returns the loaded point embeddings, keyed by UID.
+func (l *Loader) Vectors() map[string][]float32 {
+	return l.vectors
 }
 
 func (l *Loader) LoadPoints(pointsDataPath string) error {
@@ -39,17 +69,33 @@ func (l *Loader) LoadPoints(pointsDataPath string) error {
 	}
 	defer dataFile.Close()
 	scanner := bufio.NewScanner(dataFile)
+	scanner.Buffer(nil, maxLineSize)
 	for scanner.Scan() {
 		line := scanner.Bytes()
 		if len(line) == 0 {
 			continue
 		}
-		var row PointRow
-		err := json.Unmarshal(line, &row)
+		row, err := parsePointRow(line)
 		if err != nil {
 			return err
 		}
 		l.points[row.PointUID] = &scorespb.Point{Category: row.Category}
+		l.payloads[row.PointUID] = row.Payload
+		if len(row.Embedding) > 0 {
+			// The first embedding determines the dimension of the vectors
+			if l.dim == 0 {
+				l.dim = len(row.Embedding)
+			}
+			if len(row.Embedding) != l.dim {
+				return fmt.Errorf(
+					"embedding for point UID '%s' has dimension %d, expected %d",
+					row.PointUID,
+					len(row.Embedding),
+					l.dim,
+				)
+			}
+			l.vectors[row.PointUID] = row.Embedding
+		}
 	}
 	if err := scanner.Err(); err != nil {
 		return err
@@ -58,6 +104,26 @@ func (l *Loader) LoadPoints(pointsDataPath string) error {
 	return nil
 }
 
+// parsePointRow parses a row of the points dataset. Numbers in the payload are
+// kept as json.Numbers, so that integers can be told apart from floats.
+func parsePointRow(line []byte) (*PointRow, error) {
+	var row PointRow
+	if err := json.Unmarshal(line, &row); err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if err := dec.Decode(&row.Payload); err != nil {
+		return nil, err
+	}
+	delete(row.Payload, "point_uid")
+	delete(row.Payload, "embedding")
+	if row.Category == "" {
+		delete(row.Payload, store.CategoryField)
+	}
+	return &row, nil
+}
+
 func (l *Loader) LoadScores(scoresDataPath string) error {
 	// Read in the JSONL-formatted dataset source
 	dataFile, err := os.Open(scoresDataPath)
@@ -101,8 +167,16 @@ func (l *Loader) SaveScores(scoresDBPath string) error {
 	}
 	defer scoresDB.Close()
 	return scoresDB.Update(func(tx *bolt.Tx) error {
+		// Record the vector collection, which the server reads on reload
+		collections, err := tx.CreateBucketIfNotExists([]byte(store.CollectionsBucket))
+		if err != nil {
+			return err
+		}
+		if err := collections.Put([]byte(l.collection), []byte(l.vectorCollection)); err != nil {
+			return err
+		}
 		for pointUID, point := range l.points {
-			b, err := tx.CreateBucketIfNotExists([]byte(server.PointsCollection))
+			b, err := tx.CreateBucketIfNotExists([]byte(l.collection))
 			if err != nil {
 				return err
 			}
@@ -118,3 +192,33 @@ func (l *Loader) SaveScores(scoresDBPath string) error {
 		return nil
 	})
 }
+
+func (l *Loader) SaveVectors(vectorsDBPath string) error {
+	if len(l.vectors) != len(l.points) {
+		return fmt.Errorf(
+			"found embeddings for %d of %d points",
+			len(l.vectors),
+			len(l.points),
+		)
+	}
+
+	// Write to the vectors bucket of the collection
+	vectorsDB, err := bolt.Open(vectorsDBPath, 0600, nil)
+	if err != nil {
+		return err
+	}
+	defer vectorsDB.Close()
+	return vectorsDB.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(store.VectorsBucket(l.vectorCollection)))
+		if err != nil {
+			return err
+		}
+		for pointUID, vector := range l.vectors {
+			if err := b.Put([]byte(pointUID), store.EncodeVector(vector)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,135 @@
+package loader
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/pulzeai-oss/knn-router/internal/store"
+	qdrant "github.com/qdrant/go-client/qdrant"
+)
+
+// payload returns the Qdrant payload of a point: its category, and the given
+// extra fields.
+func (l *Loader) payload(pointUID string, fields []string) (map[string]*qdrant.Value, error) {
+	payload := make(map[string]*qdrant.Value)
+	for key, v := range l.payloads[pointUID] {
+		if key != store.CategoryField && !slices.Contains(fields, key) {
+			continue
+		}
+		value, err := payloadValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid payload field %s of point UID '%s': %v", key, pointUID, err)
+		}
+		payload[key] = value
+	}
+	return payload, nil
+}
+
+// payloadValue converts a value decoded from JSON, with numbers as
+// json.Numbers, into a Qdrant payload value.
+func payloadValue(v any) (*qdrant.Value, error) {
+	switch v := v.(type) {
+	case nil:
+		return &qdrant.Value{Kind: &qdrant.Value_NullValue{}}, nil
+	case bool:
+		return &qdrant.Value{Kind: &qdrant.Value_BoolValue{BoolValue: v}}, nil
+	case string:
+		return &qdrant.Value{Kind: &qdrant.Value_StringValue{StringValue: v}}, nil
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return &qdrant.Value{Kind: &qdrant.Value_IntegerValue{IntegerValue: i}}, nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return nil, err
+		}
+		return &qdrant.Value{Kind: &qdrant.Value_DoubleValue{DoubleValue: f}}, nil
+	case []any:
+		values := make([]*qdrant.Value, len(v))
+		for i, x := range v {
+			value, err := payloadValue(x)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		return &qdrant.Value{Kind: &qdrant.Value_ListValue{ListValue: &qdrant.ListValue{Values: values}}}, nil
+	case map[string]any:
+		fields := make(map[string]*qdrant.Value, len(v))
+		for key, x := range v {
+			value, err := payloadValue(x)
+			if err != nil {
+				return nil, err
+			}
+			fields[key] = value
+		}
+		return &qdrant.Value{Kind: &qdrant.Value_StructValue{StructValue: &qdrant.Struct{Fields: fields}}}, nil
+	}
+	return nil, fmt.Errorf("unsupported value type %T", v)
+}
+
+// fieldType returns the type of payload index for a value, or false if the
+// value cannot be indexed. Lists are indexed by the type of their elements.
+func fieldType(v *qdrant.Value) (qdrant.FieldType, bool) {
+	switch kind := v.GetKind().(type) {
+	case *qdrant.Value_StringValue:
+		return qdrant.FieldType_FieldTypeKeyword, true
+	case *qdrant.Value_IntegerValue:
+		return qdrant.FieldType_FieldTypeInteger, true
+	case *qdrant.Value_DoubleValue:
+		return qdrant.FieldType_FieldTypeFloat, true
+	case *qdrant.Value_BoolValue:
+		return qdrant.FieldType_FieldTypeBool, true
+	case *qdrant.Value_ListValue:
+		var listType qdrant.FieldType
+		for i, elem := range kind.ListValue.GetValues() {
+			t, ok := fieldType(elem)
+			if !ok || (i > 0 && t != listType) {
+				return 0, false
+			}
+			listType = t
+		}
+		return listType, len(kind.ListValue.GetValues()) > 0
+	}
+	return 0, false
+}
+
+// payloadIndexes returns the type of payload index of each field that has
+// the same indexable type in every point. Integer fields that also hold
+// floats are indexed as floats.
+func payloadIndexes(payloads map[string]map[string]*qdrant.Value) map[string]qdrant.FieldType {
+	types := make(map[string]qdrant.FieldType)
+	skipped := make(map[string]bool)
+	for _, payload := range payloads {
+		for key, v := range payload {
+			if skipped[key] {
+				continue
+			}
+			if _, ok := v.GetKind().(*qdrant.Value_NullValue); ok {
+				continue
+			}
+			t, ok := fieldType(v)
+			prev, seen := types[key]
+			if seen && ok && prev != t {
+				numeric := func(t qdrant.FieldType) bool {
+					return t == qdrant.FieldType_FieldTypeInteger || t == qdrant.FieldType_FieldTypeFloat
+				}
+				if numeric(prev) && numeric(t) {
+					t = qdrant.FieldType_FieldTypeFloat
+				} else {
+					ok = false
+				}
+			}
+			if !ok {
+				log.Printf("not indexing payload field %s, which has values of mixed or unsupported types", key)
+				skipped[key] = true
+				delete(types, key)
+				continue
+			}
+			types[key] = t
+		}
+	}
+	return types
+}
@@ -0,0 +1,74 @@
+package loader
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/pulzeai-oss/knn-router/internal/store"
+	qdrant "github.com/qdrant/go-client/qdrant"
+)
+
+func TestPayloadFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "points.jsonl")
+	rows := `{"point_uid":"a","category":"math","embedding":[1,0],"lang":"en","tier":1,"tags":["x","y"],"secret":"s"}
+{"point_uid":"b","category":"code","embedding":[0,1],"lang":"fr","tier":2.5,"tags":["z"],"secret":"t"}
+{"point_uid":"c","embedding":[1,1],"lang":null,"tier":3,"tags":[1],"secret":"u"}
+`
+	if err := os.WriteFile(path, []byte(rows), 0600); err != nil {
+		t.Fatalf("failed to write points: %v", err)
+	}
+	l := NewLoader(store.PointsCollection, store.PointsCollection)
+	if err := l.LoadPoints(path); err != nil {
+		t.Fatalf("failed to load points: %v", err)
+	}
+
+	fields := []string{"lang", "tier", "tags", "missing"}
+	payloads := make(map[string]map[string]*qdrant.Value)
+	for _, uid := range []string{"a", "b", "c"} {
+		payload, err := l.payload(uid, fields)
+		if err != nil {
+			t.Fatalf("payload(%s) failed: %v", uid, err)
+		}
+		payloads[uid] = payload
+	}
+
+	// Only the category and the requested fields are written
+	for uid, want := range map[string][]string{
+		"a": {"category", "lang", "tags", "tier"},
+		"b": {"category", "lang", "tags", "tier"},
+		"c": {"lang", "tags", "tier"},
+	} {
+		var keys []string
+		for key := range payloads[uid] {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		if !slices.Equal(keys, want) {
+			t.Errorf("payload of %s has fields %v, want %v", uid, keys, want)
+		}
+	}
+	if got := payloads["a"]["tier"].GetIntegerValue(); got != 1 {
+		t.Errorf("tier of a = %v, want integer 1", payloads["a"]["tier"])
+	}
+	if got := payloads["b"]["tier"].GetDoubleValue(); got != 2.5 {
+		t.Errorf("tier of b = %v, want double 2.5", payloads["b"]["tier"])
+	}
+
+	// Only the written fields with a consistent indexable type are indexed
+	indexes := payloadIndexes(payloads)
+	want := map[string]qdrant.FieldType{
+		"category": qdrant.FieldType_FieldTypeKeyword,
+		"lang":     qdrant.FieldType_FieldTypeKeyword,
+		"tier":     qdrant.FieldType_FieldTypeFloat,
+	}
+	if len(indexes) != len(want) {
+		t.Errorf("payload indexes = %v, want %v", indexes, want)
+	}
+	for field, fieldType := range want {
+		if got, ok := indexes[field]; !ok || got != fieldType {
+			t.Errorf("index of %s = %v (%v), want %v", field, got, ok, fieldType)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/pulzeai-oss/knn-router/internal/store"
+	qdrant "github.com/qdrant/go-client/qdrant"
+	"google.golang.org/grpc"
+)
+
+const (
+	SnapshotFileName = "embeddings.snapshot"
+
+	initialRetryBackoff = time.Second
+)
+
+type QdrantOpts struct {
+	Distance   qdrant.Distance
+	Recreate   bool
+	BatchSize  int
+	MaxRetries int
+	// PayloadFields are the extra fields of the points dataset written to
+	// the point payloads and indexed, besides the category.
+	PayloadFields []string
+}
+
+// SaveCollection creates the points collection in Qdrant, upserts the point
+// embeddings and payloads into it in batches, and creates payload indexes for
+// the payload fields.
+func (l *Loader) SaveCollection(
+	ctx context.Context,
+	conn *grpc.ClientConn,
+	opts QdrantOpts,
+) error {
+	if len(l.vectors) != len(l.points) {
+		return fmt.Errorf(
+			"found embeddings for %d of %d points",
+			len(l.vectors),
+			len(l.points),
+		)
+	}
+	if l.dim == 0 {
+		return fmt.Errorf("no embeddings found")
+	}
+
+	collectionsClient := qdrant.NewCollectionsClient(conn)
+	if opts.Recreate {
+		_, err := collectionsClient.Delete(ctx, &qdrant.DeleteCollection{
+			CollectionName: l.vectorCollection,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete collection: %v", err)
+		}
+	}
+	_, err := collectionsClient.Create(ctx, &qdrant.CreateCollection{
+		CollectionName: l.vectorCollection,
+		VectorsConfig: &qdrant.VectorsConfig{
+			Config: &qdrant.VectorsConfig_Params{
+				Params: &qdrant.VectorParams{
+					Size:     uint64(l.dim),
+					Distance: opts.Distance,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create collection: %v", err)
+	}
+
+	payloads := make(map[string]map[string]*qdrant.Value, len(l.points))
+	for pointUID := range l.points {
+		payloads[pointUID], err = l.payload(pointUID, opts.PayloadFields)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Upsert in a deterministic order, so that failed batches are easy to
+	// pinpoint
+	pointUIDs := make([]string, 0, len(l.vectors))
+	for pointUID := range l.vectors {
+		pointUIDs = append(pointUIDs, pointUID)
+	}
+	slices.Sort(pointUIDs)
+
+	pointsClient := qdrant.NewPointsClient(conn)
+	wait := true
+	for start := 0; start < len(pointUIDs); start += opts.BatchSize {
+		batch := pointUIDs[start:min(start+opts.BatchSize, len(pointUIDs))]
+		points := make([]*qdrant.PointStruct, len(batch))
+		for i, pointUID := range batch {
+			points[i] = &qdrant.PointStruct{
+				Id: &qdrant.PointId{
+					PointIdOptions: &qdrant.PointId_Uuid{Uuid: pointUID},
+				},
+				Vectors: &qdrant.Vectors{
+					VectorsOptions: &qdrant.Vectors_Vector{
+						Vector: &qdrant.Vector{Data: l.vectors[pointUID]},
+					},
+				},
+				Payload: payloads[pointUID],
+			}
+		}
+		err := withRetries(ctx, opts.MaxRetries, func() error {
+			_, err := pointsClient.Upsert(ctx, &qdrant.UpsertPoints{
+				CollectionName: l.vectorCollection,
+				Wait:           &wait,
+				Points:         points,
+			})
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to upsert points %d-%d: %v", start, start+len(batch), err)
+		}
+	}
+
+	indexes := payloadIndexes(payloads)
+	fields := make([]string, 0, len(indexes))
+	for field := range indexes {
+		fields = append(fields, field)
+	}
+	slices.Sort(fields)
+	for _, field := range fields {
+		fieldType := indexes[field]
+		err := withRetries(ctx, opts.MaxRetries, func() error {
+			_, err := pointsClient.CreateFieldIndex(ctx, &qdrant.CreateFieldIndexCollection{
+				CollectionName: l.vectorCollection,
+				Wait:           &wait,
+				FieldName:      field,
+				FieldType:      &fieldType,
+			})
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create payload index for field %s: %v", field, err)
+		}
+	}
+
+	return nil
+}
+
+// SaveSnapshot creates a snapshot of the points collection, and downloads it
+// from the Qdrant HTTP API into the output directory, with the API key if
+// given.
+func (l *Loader) SaveSnapshot(
+	ctx context.Context,
+	conn *grpc.ClientConn,
+	httpClient *http.Client,
+	httpURL string,
+	apiKey string,
+	outputDir string,
+) error {
+	snapshotResp, err := qdrant.NewSnapshotsClient(conn).Create(ctx, &qdrant.CreateSnapshotRequest{
+		CollectionName: l.vectorCollection,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %v", err)
+	}
+
+	snapshotURL, err := url.JoinPath(
+		httpURL,
+		"collections",
+		l.vectorCollection,
+		"snapshots",
+		snapshotResp.GetSnapshotDescription().GetName(),
+	)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, snapshotURL, nil)
+	if err != nil {
+		return err
+	}
+	if apiKey != "" {
+		req.Header.Set(store.QdrantAPIKeyHeader, apiKey)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download snapshot: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download snapshot: unexpected status %d", resp.StatusCode)
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return err
+	}
+	snapshotFile, err := os.Create(filepath.Join(outputDir, SnapshotFileName))
+	if err != nil {
+		return err
+	}
+	defer snapshotFile.Close()
+	if _, err := io.Copy(snapshotFile, resp.Body); err != nil {
+		return fmt.Errorf("failed to write snapshot: %v", err)
+	}
+	return snapshotFile.Close()
+}
+
+// withRetries calls fn until it succeeds, retrying up to maxRetries times with
+// exponential backoff.
+func withRetries(ctx context.Context, maxRetries int, fn func() error) error {
+	backoff := initialRetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= maxRetries {
+			return err
+		}
+		log.Printf("attempt %d failed, retrying in %v: %v", attempt+1, backoff, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
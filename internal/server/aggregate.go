@@ -0,0 +1,186 @@
+package server
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/pulzeai-oss/knn-router/internal/scorespb"
+)
+
+// PointLookup returns the category and target scores of the point with the
+// given UID.
+type PointLookup func(uid string) (*scorespb.Point, error)
+
+// Aggregate computes the weighted average score for each target over the
+// given nearest neighbors, sorted with SortScores. If the weights sum to
+// zero, the neighbors are weighted uniformly instead.
+func Aggregate(
+	neighbors []Neighbor,
+	weighting Weighting,
+	lookup PointLookup,
+) (*Response, error) {
+	// Initialize response
+	res := Response{Weighting: weighting}
+
+	// Aggregate scores from nearest neighbors
+	weights, weightSum := neighborWeights(neighbors, weighting)
+	scoresSum := make(map[string]float32)
+	for i, neighbor := range neighbors {
+		uid := neighbor.ID
+		weight := weights[i]
+		payload, err := lookup(uid)
+		if err != nil {
+			return nil, lookupError(uid, err)
+		}
+		res.Hits = append(
+			res.Hits,
+			Hit{
+				ID:         uid,
+				Category:   payload.GetCategory(),
+				Similarity: neighbor.Similarity,
+				Weight:     weight,
+			},
+		)
+		for _, score := range payload.GetScores() {
+			scoresSum[score.GetTarget()] += score.GetScore() * weight
+		}
+	}
+	// Normalize the accumulated scores by dividing by the sum of weighted distances
+	for target, score := range scoresSum {
+		res.Scores = append(
+			res.Scores,
+			Score{Target: target, Score: score / weightSum},
+		)
+	}
+	SortScores(res.Scores)
+	return &res, nil
+}
+
+// missingPointError is the error of a lookup of a point that is not in the
+// scores database.
+func missingPointError(uid string) error {
+	return fmt.Errorf("could not find targets for nearest neighbor UID %s", uid)
+}
+
+// lookupError wraps an error looking up the target scores of a neighbor.
+func lookupError(uid string, err error) error {
+	return fmt.Errorf("failed to retrieve targets for nearest neighbor UID %s: %v", uid, err)
+}
+
+// neighborWeights returns the weight of each neighbor and their sum, falling
+// back to uniform weights if the weights sum to zero.
+func neighborWeights(neighbors []Neighbor, weighting Weighting) ([]float32, float32) {
+	weights := weighting.Weights(neighbors)
+	var weightSum float32
+	for _, weight := range weights {
+		weightSum += weight
+	}
+	if weightSum == 0 {
+		weights = UniformWeighting.Weights(neighbors)
+		weightSum = float32(len(weights))
+	}
+	return weights, weightSum
+}
+
+// ErrNoTargets is returned when every target is filtered out of a response.
+var ErrNoTargets = errors.New("no targets left after applying allowed_targets and excluded_targets")
+
+// FilterTargets keeps the scores of the allowed targets that are not
+// excluded, and renormalizes them to sum to 1, unless they sum to zero. An
+// empty allowed list allows every target.
+func FilterTargets(scores []Score, allowed, excluded []string) ([]Score, error) {
+	if len(allowed) == 0 && len(excluded) == 0 {
+		return scores, nil
+	}
+	var kept float32
+	var filtered []Score
+	for _, score := range scores {
+		if (len(allowed) > 0 && !slices.Contains(allowed, score.Target)) ||
+			slices.Contains(excluded, score.Target) {
+			continue
+		}
+		kept += score.Score
+		filtered = append(filtered, score)
+	}
+	if len(filtered) == 0 {
+		return nil, ErrNoTargets
+	}
+	if kept > 0 {
+		for i := range filtered {
+			filtered[i].Score /= kept
+		}
+	}
+	return filtered, nil
+}
+
+// compareScores orders scores from highest to lowest, breaking ties by target
+// name.
+func compareScores(a, b Score) int {
+	if a.Score != b.Score {
+		return cmp.Compare(b.Score, a.Score)
+	}
+	return strings.Compare(a.Target, b.Target)
+}
+
+// SortScores sorts scores from highest to lowest, breaking ties by target
+// name.
+func SortScores(scores []Score) {
+	slices.SortFunc(scores, compareScores)
+}
+
+// Best returns the highest score, breaking ties by target name.
+func Best(scores []Score) (Score, bool) {
+	if len(scores) == 0 {
+		return Score{}, false
+	}
+	return slices.MinFunc(scores, compareScores), true
+}
+
+// Decide chooses the first of the given sorted scores, along with its margin
+// over the runner-up and up to the given number of alternates. It returns nil
+// if there are no scores.
+func Decide(scores []Score, alternates int) *Decision {
+	if len(scores) == 0 {
+		return nil
+	}
+	decision := Decision{
+		Target:     scores[0].Target,
+		Score:      scores[0].Score,
+		Margin:     scores[0].Score,
+		Alternates: slices.Clone(scores[1:min(len(scores), alternates+1)]),
+	}
+	if len(scores) > 1 {
+		decision.Margin -= scores[1].Score
+	}
+	return &decision
+}
+
+// roundScores rounds the scores and the decision of a response to two
+// decimal places. Scores are ranked and decided on at full precision, and
+// only rounded for the response. The decision must have been made on the
+// sorted scores of the response, so that its margin is recomputed from the
+// rounded scores of the decision and the runner-up.
+func roundScores(res *Response) {
+	for i := range res.Scores {
+		res.Scores[i].Score = roundScore(res.Scores[i].Score)
+	}
+	if res.Decision != nil {
+		res.Decision.Score = roundScore(res.Decision.Score)
+		res.Decision.Margin = res.Decision.Score
+		if len(res.Scores) > 1 {
+			res.Decision.Margin = roundScore(res.Decision.Margin - res.Scores[1].Score)
+		}
+		for i := range res.Decision.Alternates {
+			res.Decision.Alternates[i].Score = roundScore(res.Decision.Alternates[i].Score)
+		}
+	}
+}
+
+// roundScore rounds a score to two decimal places.
+func roundScore(score float32) float32 {
+	return float32(math.Round(float64(score)*100)) / 100
+}
@@ -0,0 +1,190 @@
+package server
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestFilterTargets(t *testing.T) {
+	scores := []Score{{Target: "a", Score: 0.5}, {Target: "b", Score: 0.3}, {Target: "c", Score: 0.2}}
+	for _, tt := range []struct {
+		name     string
+		scores   []Score
+		allowed  []string
+		excluded []string
+		want     []Score
+		wantErr  error
+	}{
+		{
+			name:   "no filters",
+			scores: scores,
+			want:   scores,
+		},
+		{
+			name:    "allowed only",
+			scores:  scores,
+			allowed: []string{"b", "c"},
+			want:    []Score{{Target: "b", Score: 0.6}, {Target: "c", Score: 0.4}},
+		},
+		{
+			name:     "excluded only",
+			scores:   scores,
+			excluded: []string{"a"},
+			want:     []Score{{Target: "b", Score: 0.6}, {Target: "c", Score: 0.4}},
+		},
+		{
+			name:     "allowed and excluded",
+			scores:   scores,
+			allowed:  []string{"a", "b"},
+			excluded: []string{"a"},
+			want:     []Score{{Target: "b", Score: 1}},
+		},
+		{
+			name:     "everything filtered out",
+			scores:   scores,
+			allowed:  []string{"a"},
+			excluded: []string{"a"},
+			wantErr:  ErrNoTargets,
+		},
+		{
+			name:    "unknown allowed target",
+			scores:  scores,
+			allowed: []string{"d"},
+			wantErr: ErrNoTargets,
+		},
+		{
+			name:     "zero sum is kept as is",
+			scores:   []Score{{Target: "a", Score: 1}, {Target: "b", Score: 0}, {Target: "c", Score: 0}},
+			excluded: []string{"a"},
+			want:     []Score{{Target: "b", Score: 0}, {Target: "c", Score: 0}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			input := slices.Clone(tt.scores)
+			got, err := FilterTargets(input, tt.allowed, tt.excluded)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("FilterTargets() error = %v, want %v", err, tt.wantErr)
+			}
+			if !equalScores(got, tt.want) {
+				t.Errorf("FilterTargets() = %v, want %v", got, tt.want)
+			}
+			if !slices.Equal(input, tt.scores) {
+				t.Errorf("FilterTargets() modified its input to %v", input)
+			}
+		})
+	}
+}
+
+// equalScores reports whether got and want have the same targets in the same
+// order, with scores within 1e-5 of each other.
+func equalScores(got, want []Score) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i].Target != want[i].Target || !approxEqual([]float32{got[i].Score}, []float32{want[i].Score}) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSortScoresBreaksTiesByTarget(t *testing.T) {
+	scores := []Score{{Target: "c", Score: 0.25}, {Target: "b", Score: 0.5}, {Target: "d", Score: 0.25}, {Target: "a", Score: 0.25}}
+	SortScores(scores)
+	want := []Score{{Target: "b", Score: 0.5}, {Target: "a", Score: 0.25}, {Target: "c", Score: 0.25}, {Target: "d", Score: 0.25}}
+	if !slices.Equal(scores, want) {
+		t.Errorf("SortScores() = %v, want %v", scores, want)
+	}
+}
+
+func TestBest(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		scores []Score
+		want   Score
+		wantOK bool
+	}{
+		{name: "empty"},
+		{
+			name:   "highest score",
+			scores: []Score{{Target: "a", Score: 0.2}, {Target: "b", Score: 0.8}},
+			want:   Score{Target: "b", Score: 0.8},
+			wantOK: true,
+		},
+		{
+			name:   "tie broken by target",
+			scores: []Score{{Target: "c", Score: 0.4}, {Target: "b", Score: 0.4}, {Target: "a", Score: 0.2}},
+			want:   Score{Target: "b", Score: 0.4},
+			wantOK: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Best(tt.scores)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("Best() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestDecide(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		scores     []Score
+		alternates int
+		want       *Decision
+	}{
+		{name: "empty", alternates: 2},
+		{
+			name:       "single target",
+			scores:     []Score{{Target: "a", Score: 1}},
+			alternates: 2,
+			want:       &Decision{Target: "a", Score: 1, Margin: 1, Alternates: []Score{}},
+		},
+		{
+			name:       "tie",
+			scores:     []Score{{Target: "a", Score: 0.4}, {Target: "b", Score: 0.4}, {Target: "c", Score: 0.2}},
+			alternates: 1,
+			want: &Decision{
+				Target:     "a",
+				Score:      0.4,
+				Margin:     0,
+				Alternates: []Score{{Target: "b", Score: 0.4}},
+			},
+		},
+		{
+			name:       "no alternates",
+			scores:     []Score{{Target: "a", Score: 0.75}, {Target: "b", Score: 0.25}},
+			alternates: 0,
+			want:       &Decision{Target: "a", Score: 0.75, Margin: 0.5, Alternates: []Score{}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Decide(tt.scores, tt.alternates)
+			if (got == nil) != (tt.want == nil) {
+				t.Fatalf("Decide() = %v, want %v", got, tt.want)
+			}
+			if got == nil {
+				return
+			}
+			if got.Target != tt.want.Target ||
+				got.Score != tt.want.Score ||
+				got.Margin != tt.want.Margin ||
+				!slices.Equal(got.Alternates, tt.want.Alternates) {
+				t.Errorf("Decide() = %+v, want %+v", *got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundScoresMargin(t *testing.T) {
+	// The unrounded margin of 0.112 would be rounded to 0.11
+	res := &Response{Scores: []Score{{Target: "a", Score: 0.556}, {Target: "b", Score: 0.444}}}
+	res.Decision = Decide(res.Scores, 1)
+	roundScores(res)
+	if d := res.Decision; d.Score != 0.56 || d.Alternates[0].Score != 0.44 || d.Margin != 0.12 {
+		t.Errorf("rounded decision = %+v, want score 0.56, runner-up 0.44 and margin 0.12", *d)
+	}
+}
@@ -0,0 +1,372 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// KeyDefaults are request options applied to the requests made with an API
+// key that leave them unset.
+type KeyDefaults struct {
+	AllowedTargets  []string       `json:"allowed_targets,omitempty"`
+	ExcludedTargets []string       `json:"excluded_targets,omitempty"`
+	TopK            *int           `json:"top_k,omitempty"`
+	MinSimilarity   *float32       `json:"min_similarity,omitempty"`
+	Weighting       *Weighting     `json:"weighting,omitempty"`
+	TurnSelection   *TurnSelection `json:"turn_selection,omitempty"`
+	Filter          *Filter        `json:"filter,omitempty"`
+}
+
+func (d *KeyDefaults) apply(req *Request) {
+	if len(req.AllowedTargets) == 0 {
+		req.AllowedTargets = d.AllowedTargets
+	}
+	if len(req.ExcludedTargets) == 0 {
+		req.ExcludedTargets = d.ExcludedTargets
+	}
+	if req.TopK == nil {
+		req.TopK = d.TopK
+	}
+	if req.MinSimilarity == nil {
+		req.MinSimilarity = d.MinSimilarity
+	}
+	if req.Weighting == nil {
+		req.Weighting = d.Weighting
+	}
+	if req.TurnSelection == nil {
+		req.TurnSelection = d.TurnSelection
+	}
+	if req.Filter == nil {
+		req.Filter = d.Filter
+	}
+}
+
+// KeyConfig is an API key in the keys file. RateLimit is the number of
+// requests per second allowed with the key, or 0 for no limit, and Burst is
+// the number of requests that may be made at once, which defaults to the
+// rate limit rounded up. Each query of a batch counts as a request.
+type KeyConfig struct {
+	Name      string       `json:"name"`
+	Key       string       `json:"key"`
+	RateLimit float64      `json:"rate_limit"`
+	Burst     int          `json:"burst"`
+	Defaults  *KeyDefaults `json:"defaults"`
+}
+
+type keysFile struct {
+	Keys []KeyConfig `json:"keys"`
+}
+
+// clientKey is a loaded API key, with the token bucket of its rate limit.
+type clientKey struct {
+	KeyConfig
+	bucket *tokenBucket
+}
+
+type clientKeyContextKey struct{}
+
+// applyKeyDefaults applies the defaults of the API key that authenticated the
+// request, if any.
+func applyKeyDefaults(ctx context.Context, req *Request) {
+	key, _ := ctx.Value(clientKeyContextKey{}).(*clientKey)
+	if key != nil && key.Defaults != nil {
+		key.Defaults.apply(req)
+	}
+}
+
+// tokenBucket allows rate requests per second on average, and up to burst
+// requests at once.
+type tokenBucket struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// take takes n tokens from the bucket, or returns how long until they are
+// available.
+func (b *tokenBucket) take(n float64) (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	if b.tokens >= n {
+		b.tokens -= n
+		return 0, true
+	}
+	return time.Duration((n - b.tokens) / b.rate * float64(time.Second)), false
+}
+
+// keyDigest hashes an API key, so that keys are looked up and compared in
+// time independent of their contents.
+func keyDigest(key string) [sha256.Size]byte {
+	return sha256.Sum256([]byte(key))
+}
+
+// matchKey reports whether a token is the key with the given digest, in
+// constant time.
+func matchKey(token string, digest [sha256.Size]byte) bool {
+	tokenDigest := keyDigest(token)
+	return subtle.ConstantTimeCompare(tokenDigest[:], digest[:]) == 1
+}
+
+// Authenticator authenticates requests with bearer API keys loaded from a
+// file, and rate limits each key. Keys are indexed by their digest.
+type Authenticator struct {
+	path string
+
+	mu   sync.RWMutex
+	keys map[[sha256.Size]byte]*clientKey
+}
+
+func NewAuthenticator(path string) (*Authenticator, error) {
+	a := &Authenticator{path: path}
+	if err := a.Reload(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Reload loads the keys file. Keys whose name and rate limit are unchanged
+// keep their token buckets. The current keys are kept if the file is invalid.
+func (a *Authenticator) Reload() error {
+	data, err := os.ReadFile(a.path)
+	if err != nil {
+		return fmt.Errorf("failed to read keys file: %v", err)
+	}
+	var file keysFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse keys file: %v", err)
+	}
+
+	a.mu.RLock()
+	buckets := make(map[string]*clientKey, len(a.keys))
+	for _, key := range a.keys {
+		buckets[key.Name] = key
+	}
+	a.mu.RUnlock()
+
+	keys := make(map[[sha256.Size]byte]*clientKey, len(file.Keys))
+	names := make(map[string]bool, len(file.Keys))
+	for _, config := range file.Keys {
+		if config.Name == "" || config.Key == "" {
+			return fmt.Errorf("every key requires a name and a key")
+		}
+		if names[config.Name] {
+			return fmt.Errorf("duplicate key name %s", config.Name)
+		}
+		names[config.Name] = true
+		digest := keyDigest(config.Key)
+		if _, exists := keys[digest]; exists {
+			return fmt.Errorf("key %s duplicates another key", config.Name)
+		}
+		if config.RateLimit < 0 || config.Burst < 0 {
+			return fmt.Errorf("rate limit of key %s must not be negative", config.Name)
+		}
+		if config.Burst == 0 {
+			config.Burst = max(int(math.Ceil(config.RateLimit)), 1)
+		}
+		if config.Defaults != nil && config.Defaults.Filter != nil {
+			if err := config.Defaults.Filter.Validate(); err != nil {
+				return fmt.Errorf("invalid default filter of key %s: %v", config.Name, err)
+			}
+		}
+		key := &clientKey{KeyConfig: config}
+		prev, ok := buckets[config.Name]
+		switch {
+		case config.RateLimit == 0:
+		case ok && prev.bucket != nil && prev.RateLimit == config.RateLimit && prev.Burst == config.Burst:
+			key.bucket = prev.bucket
+		default:
+			key.bucket = newTokenBucket(config.RateLimit, config.Burst)
+		}
+		keys[digest] = key
+	}
+
+	a.mu.Lock()
+	a.keys = keys
+	a.mu.Unlock()
+	return nil
+}
+
+// Watch polls the keys file, and reloads it when it changes.
+func (a *Authenticator) Watch(ctx context.Context, interval time.Duration) {
+	watchFile(ctx, a.path, interval, func() {
+		if err := a.Reload(); err != nil {
+			log.Printf("failed to reload keys file: %v", err)
+			return
+		}
+		log.Printf("reloaded keys file from %s", a.path)
+	})
+}
+
+// authorize authenticates a bearer token and takes a token from the rate
+// limit of its key. It returns the gRPC status of a rejected request, and
+// how long to wait before retrying a rate limited one.
+func (a *Authenticator) authorize(authorization string) (*clientKey, codes.Code, time.Duration) {
+	token, ok := strings.CutPrefix(authorization, "Bearer ")
+	digest := keyDigest(token)
+	a.mu.RLock()
+	key := a.keys[digest]
+	a.mu.RUnlock()
+	if !ok || key == nil || !matchKey(key.Key, digest) {
+		authFailuresTotal.Inc()
+		return nil, codes.Unauthenticated, 0
+	}
+	if key.bucket != nil {
+		if retryAfter, ok := key.bucket.take(1); !ok {
+			clientRequestsTotal.WithLabelValues(key.Name, "rate_limited").Inc()
+			return nil, codes.ResourceExhausted, retryAfter
+		}
+	}
+	clientRequestsTotal.WithLabelValues(key.Name, "ok").Inc()
+	return key, codes.OK, 0
+}
+
+// chargeQueries takes a token from the rate limit of the API key that
+// authenticated a batch for each of its queries after the first, which was
+// taken when the batch was authorized. It returns a status error if the batch
+// is rejected, along with how long to wait before retrying a rate limited
+// one.
+func chargeQueries(ctx context.Context, queries int) (time.Duration, error) {
+	key, _ := ctx.Value(clientKeyContextKey{}).(*clientKey)
+	if key == nil || key.bucket == nil || queries <= 1 {
+		return 0, nil
+	}
+	if queries > key.Burst {
+		return 0, status.Errorf(
+			codes.InvalidArgument,
+			"batch of %d queries exceeds the burst of the API key (%d)",
+			queries,
+			key.Burst,
+		)
+	}
+	if retryAfter, ok := key.bucket.take(float64(queries - 1)); !ok {
+		clientRequestsTotal.WithLabelValues(key.Name, "rate_limited").Inc()
+		return retryAfter, status.Error(codes.ResourceExhausted, "rate limit exceeded")
+	}
+	return 0, nil
+}
+
+// chargeBatch charges the queries of an HTTP batch request to its API key,
+// and writes the error if the batch is rejected.
+func chargeBatch(w http.ResponseWriter, r *http.Request, queries int) bool {
+	retryAfter, err := chargeQueries(r.Context(), queries)
+	switch status.Code(err) {
+	case codes.InvalidArgument:
+		http.Error(w, status.Convert(err).Message(), http.StatusBadRequest)
+		return false
+	case codes.ResourceExhausted:
+		w.Header().Set("Retry-After", retryAfterSeconds(retryAfter))
+		http.Error(w, status.Convert(err).Message(), http.StatusTooManyRequests)
+		return false
+	}
+	return true
+}
+
+// retryAfterSeconds rounds a delay up to whole seconds, for Retry-After.
+func retryAfterSeconds(d time.Duration) string {
+	return strconv.Itoa(max(int(math.Ceil(d.Seconds())), 1))
+}
+
+// middleware authenticates and rate limits HTTP requests, writing errors with
+// writeError. A nil Authenticator lets every request through.
+func (a *Authenticator) middleware(
+	handler http.HandlerFunc,
+	writeError func(w http.ResponseWriter, status int, message string),
+) http.HandlerFunc {
+	if a == nil {
+		return handler
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		key, code, retryAfter := a.authorize(r.Header.Get("Authorization"))
+		switch code {
+		case codes.Unauthenticated:
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, "missing or invalid API key")
+			return
+		case codes.ResourceExhausted:
+			w.Header().Set("Retry-After", retryAfterSeconds(retryAfter))
+			writeError(w, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+		handler(w, r.WithContext(context.WithValue(r.Context(), clientKeyContextKey{}, key)))
+	}
+}
+
+// adminMiddleware requires HTTP requests to present the admin key as a bearer
+// token.
+func adminMiddleware(adminKey string, handler http.HandlerFunc) http.HandlerFunc {
+	digest := keyDigest(adminKey)
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || !matchKey(token, digest) {
+			authFailuresTotal.Inc()
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "missing or invalid admin key", http.StatusUnauthorized)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// httpError writes an error like http.Error.
+func httpError(w http.ResponseWriter, status int, message string) {
+	http.Error(w, message, status)
+}
+
+// unaryInterceptor authenticates and rate limits calls to the given gRPC
+// service, with the bearer token in the authorization metadata.
+func (a *Authenticator) unaryInterceptor(service string) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		if a == nil || !strings.HasPrefix(info.FullMethod, "/"+service+"/") {
+			return handler(ctx, req)
+		}
+		var authorization string
+		if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get("authorization")) > 0 {
+			authorization = md.Get("authorization")[0]
+		}
+		key, code, retryAfter := a.authorize(authorization)
+		switch code {
+		case codes.Unauthenticated:
+			return nil, status.Error(codes.Unauthenticated, "missing or invalid API key")
+		case codes.ResourceExhausted:
+			grpc.SetHeader(ctx, metadata.Pairs("retry-after", retryAfterSeconds(retryAfter)))
+			return nil, status.Error(codes.ResourceExhausted, "rate limit exceeded")
+		}
+		return handler(context.WithValue(ctx, clientKeyContextKey{}, key), req)
+	}
+}
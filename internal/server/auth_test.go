@@ -0,0 +1,158 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// elapse moves the last refill of a token bucket back by d.
+func elapse(b *tokenBucket, d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.last = b.last.Add(-d)
+}
+
+func TestTokenBucketTake(t *testing.T) {
+	b := newTokenBucket(2, 3)
+	for i := 0; i < 3; i++ {
+		if _, ok := b.take(1); !ok {
+			t.Fatalf("take %d failed within the burst", i)
+		}
+	}
+	retryAfter, ok := b.take(1)
+	if ok {
+		t.Fatal("take succeeded on an empty bucket")
+	}
+	if retryAfter <= 0 || retryAfter > 500*time.Millisecond {
+		t.Errorf("retry after = %v, want at most 500ms", retryAfter)
+	}
+
+	// Tokens refill at the rate, up to the burst
+	elapse(b, time.Second)
+	if _, ok := b.take(2); !ok {
+		t.Error("take of 2 tokens failed after refilling for a second")
+	}
+	elapse(b, time.Hour)
+	if _, ok := b.take(3); !ok {
+		t.Error("take of the burst failed after refilling")
+	}
+	if _, ok := b.take(0.5); ok {
+		t.Error("bucket refilled past its burst")
+	}
+}
+
+func TestChargeQueries(t *testing.T) {
+	newKey := func(rateLimit float64, burst int) *clientKey {
+		return &clientKey{
+			KeyConfig: KeyConfig{Name: "test", RateLimit: rateLimit, Burst: burst},
+			bucket:    newTokenBucket(rateLimit, burst),
+		}
+	}
+	for _, tt := range []struct {
+		name     string
+		key      *clientKey
+		taken    float64
+		queries  int
+		wantCode codes.Code
+	}{
+		{name: "no key", queries: 100, wantCode: codes.OK},
+		{name: "unlimited key", key: &clientKey{KeyConfig: KeyConfig{Name: "test"}}, queries: 100, wantCode: codes.OK},
+		{name: "single query", key: newKey(1, 1), taken: 1, queries: 1, wantCode: codes.OK},
+		{name: "within the burst", key: newKey(1, 5), taken: 1, queries: 5, wantCode: codes.OK},
+		{name: "exceeds the burst", key: newKey(1, 5), taken: 1, queries: 6, wantCode: codes.InvalidArgument},
+		{name: "rate limited", key: newKey(1, 5), taken: 3, queries: 4, wantCode: codes.ResourceExhausted},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.key != nil {
+				ctx = context.WithValue(ctx, clientKeyContextKey{}, tt.key)
+				if tt.key.bucket != nil {
+					// The first query is taken when the batch is authorized
+					tt.key.bucket.take(tt.taken)
+				}
+			}
+			retryAfter, err := chargeQueries(ctx, tt.queries)
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("chargeQueries() = %v, want %v", err, tt.wantCode)
+			}
+			if tt.wantCode == codes.ResourceExhausted && retryAfter <= 0 {
+				t.Errorf("retry after = %v, want a positive delay", retryAfter)
+			}
+		})
+	}
+}
+
+func writeKeysFile(t *testing.T, path, keys string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(keys), 0600); err != nil {
+		t.Fatalf("failed to write keys file: %v", err)
+	}
+}
+
+func TestAuthenticatorReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	writeKeysFile(t, path, `{"keys":[
+		{"name":"alice","key":"alice-key","rate_limit":1},
+		{"name":"bob","key":"bob-key","rate_limit":1}
+	]}`)
+	a, err := NewAuthenticator(path)
+	if err != nil {
+		t.Fatalf("NewAuthenticator failed: %v", err)
+	}
+	if _, code, _ := a.authorize("Bearer alice-key"); code != codes.OK {
+		t.Fatalf("authorize(alice) = %v, want OK", code)
+	}
+	bob, _, _ := a.authorize("Bearer bob-key")
+	for _, authorization := range []string{"alice-key", "Bearer alice", "Bearer alice-key ", "Bearer "} {
+		if _, code, _ := a.authorize(authorization); code != codes.Unauthenticated {
+			t.Errorf("authorize(%q) = %v, want Unauthenticated", authorization, code)
+		}
+	}
+
+	// Alice's key is rotated and bob's rate limit is raised
+	writeKeysFile(t, path, `{"keys":[
+		{"name":"alice","key":"alice-key-2","rate_limit":1},
+		{"name":"bob","key":"bob-key","rate_limit":2}
+	]}`)
+	if err := a.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if _, code, _ := a.authorize("Bearer alice-key"); code != codes.Unauthenticated {
+		t.Errorf("authorize(old alice key) = %v, want Unauthenticated", code)
+	}
+	// Alice's bucket was drained by the first request, and is kept
+	if _, code, _ := a.authorize("Bearer alice-key-2"); code != codes.ResourceExhausted {
+		t.Errorf("authorize(new alice key) = %v, want ResourceExhausted", code)
+	}
+	newBob, code, _ := a.authorize("Bearer bob-key")
+	if code != codes.OK {
+		t.Fatalf("authorize(bob) = %v, want OK", code)
+	}
+	if newBob.bucket == bob.bucket {
+		t.Error("bob's key kept its token bucket after its rate limit changed")
+	}
+
+	// Invalid files keep the current keys
+	for _, keys := range []string{
+		`not json`,
+		`{"keys":[{"name":"alice","key":""}]}`,
+		`{"keys":[{"name":"alice","key":"a"},{"name":"alice","key":"b"}]}`,
+		`{"keys":[{"name":"alice","key":"a"},{"name":"bob","key":"a"}]}`,
+		`{"keys":[{"name":"alice","key":"a","rate_limit":-1}]}`,
+		`{"keys":[{"name":"alice","key":"a","defaults":{"filter":{}}}]}`,
+	} {
+		writeKeysFile(t, path, keys)
+		if err := a.Reload(); err == nil {
+			t.Errorf("Reload succeeded with keys file %s", keys)
+		}
+	}
+	if key, code, _ := a.authorize("Bearer bob-key"); code != codes.OK || key.bucket != newBob.bucket {
+		t.Errorf("authorize(bob) after invalid reloads = %v, want OK with the same bucket", code)
+	}
+}
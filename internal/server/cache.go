@@ -0,0 +1,189 @@
+package server
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	cacheOpTokenize = "tokenize"
+	cacheOpEmbed    = "embed"
+)
+
+type cacheBypassKey struct{}
+
+// WithCacheBypass returns a context for which CachedEmbedder does not look up
+// cached results. Fresh results are still cached.
+func WithCacheBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheBypassKey{}, true)
+}
+
+func cacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(cacheBypassKey{}).(bool)
+	return bypass
+}
+
+type cacheKey struct {
+	op    string
+	model string
+	input string
+}
+
+type cacheEntry struct {
+	key     cacheKey
+	value   any
+	size    int
+	expires time.Time
+}
+
+// CachedEmbedder is an Embedder that caches the tokens and embeddings computed
+// by another Embedder in an LRU cache, keyed by the model ID and the input
+// text. Entries expire after the TTL, and the least recently used entries are
+// evicted once the cache holds more than maxEntries entries or maxBytes bytes.
+type CachedEmbedder struct {
+	Embedder
+	model      string
+	ttl        time.Duration
+	maxEntries int
+	maxBytes   int
+
+	mu      sync.Mutex
+	entries map[cacheKey]*list.Element
+	lru     *list.List
+	bytes   int
+}
+
+func NewCachedEmbedder(embedder Embedder, ttl time.Duration, maxEntries, maxBytes int) *CachedEmbedder {
+	return &CachedEmbedder{
+		Embedder:   embedder,
+		model:      embedder.Info().ModelID,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		entries:    make(map[cacheKey]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+func (c *CachedEmbedder) get(ctx context.Context, op, input string) (any, bool) {
+	if cacheBypassed(ctx) {
+		embedCacheRequestsTotal.WithLabelValues(op, "bypass").Inc()
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[cacheKey{op: op, model: c.model, input: input}]
+	if ok && time.Now().After(elem.Value.(*cacheEntry).expires) {
+		c.remove(elem)
+		ok = false
+	}
+	if !ok {
+		embedCacheRequestsTotal.WithLabelValues(op, "miss").Inc()
+		return nil, false
+	}
+	embedCacheRequestsTotal.WithLabelValues(op, "hit").Inc()
+	c.lru.MoveToFront(elem)
+	return elem.Value.(*cacheEntry).value, true
+}
+
+func (c *CachedEmbedder) put(op, input string, value any, size int) {
+	key := cacheKey{op: op, model: c.model, input: input}
+	size += len(input)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{
+		key:     key,
+		value:   value,
+		size:    size,
+		expires: time.Now().Add(c.ttl),
+	})
+	c.bytes += size
+	for c.lru.Len() > c.maxEntries || (c.maxBytes > 0 && c.bytes > c.maxBytes) {
+		c.remove(c.lru.Back())
+		embedCacheEvictionsTotal.Inc()
+	}
+	embedCacheBytes.Set(float64(c.bytes))
+}
+
+func (c *CachedEmbedder) remove(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*cacheEntry)
+	delete(c.entries, entry.key)
+	c.bytes -= entry.size
+	embedCacheBytes.Set(float64(c.bytes))
+}
+
+func (c *CachedEmbedder) Tokenize(ctx context.Context, input string) ([]Token, error) {
+	if tokens, ok := c.get(ctx, cacheOpTokenize, input); ok {
+		return tokens.([]Token), nil
+	}
+	tokens, err := c.Embedder.Tokenize(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	c.put(cacheOpTokenize, input, tokens, 16*len(tokens))
+	return tokens, nil
+}
+
+func (c *CachedEmbedder) TokenizeBatch(ctx context.Context, inputs []string) ([][]Token, error) {
+	return cachedBatch(ctx, c, cacheOpTokenize, inputs, c.Embedder.TokenizeBatch, func(tokens []Token) int {
+		return 16 * len(tokens)
+	})
+}
+
+func (c *CachedEmbedder) Embed(ctx context.Context, input string) ([]float32, error) {
+	if vector, ok := c.get(ctx, cacheOpEmbed, input); ok {
+		return vector.([]float32), nil
+	}
+	vector, err := c.Embedder.Embed(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	c.put(cacheOpEmbed, input, vector, 4*len(vector))
+	return vector, nil
+}
+
+func (c *CachedEmbedder) EmbedBatch(ctx context.Context, inputs []string) ([][]float32, error) {
+	return cachedBatch(ctx, c, cacheOpEmbed, inputs, c.Embedder.EmbedBatch, func(vector []float32) int {
+		return 4 * len(vector)
+	})
+}
+
+// cachedBatch looks up each input in the cache, and computes the results of
+// the missing inputs in a single batch.
+func cachedBatch[T any](
+	ctx context.Context,
+	c *CachedEmbedder,
+	op string,
+	inputs []string,
+	compute func(ctx context.Context, inputs []string) ([]T, error),
+	size func(T) int,
+) ([]T, error) {
+	results := make([]T, len(inputs))
+	var missing []int
+	var missingInputs []string
+	for i, input := range inputs {
+		if v, ok := c.get(ctx, op, input); ok {
+			results[i] = v.(T)
+			continue
+		}
+		missing = append(missing, i)
+		missingInputs = append(missingInputs, input)
+	}
+	if len(missing) == 0 {
+		return results, nil
+	}
+	computed, err := compute(ctx, missingInputs)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range missing {
+		results[i] = computed[j]
+		c.put(op, inputs[i], computed[j], size(computed[j]))
+	}
+	return results, nil
+}
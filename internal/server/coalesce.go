@@ -0,0 +1,150 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CoalescingEmbedder is an Embedder that collects concurrent single Tokenize
+// and Embed calls for up to a window, or until maxBatchSize calls are
+// waiting, and sends them to another Embedder as a single batch.
+type CoalescingEmbedder struct {
+	Embedder
+	tokenize *coalescer[[]Token]
+	embed    *coalescer[[]float32]
+}
+
+func NewCoalescingEmbedder(embedder Embedder, window time.Duration, maxBatchSize int) *CoalescingEmbedder {
+	return &CoalescingEmbedder{
+		Embedder: embedder,
+		tokenize: &coalescer[[]Token]{
+			op:           cacheOpTokenize,
+			batch:        embedder.TokenizeBatch,
+			window:       window,
+			maxBatchSize: maxBatchSize,
+		},
+		embed: &coalescer[[]float32]{
+			op:           cacheOpEmbed,
+			batch:        embedder.EmbedBatch,
+			window:       window,
+			maxBatchSize: maxBatchSize,
+		},
+	}
+}
+
+func (c *CoalescingEmbedder) Tokenize(ctx context.Context, input string) ([]Token, error) {
+	return c.tokenize.do(ctx, input)
+}
+
+func (c *CoalescingEmbedder) Embed(ctx context.Context, input string) ([]float32, error) {
+	return c.embed.do(ctx, input)
+}
+
+type coalescedCall[T any] struct {
+	ctx    context.Context
+	input  string
+	result T
+	err    error
+	done   chan struct{}
+}
+
+type coalescer[T any] struct {
+	op           string
+	batch        func(ctx context.Context, inputs []string) ([]T, error)
+	window       time.Duration
+	maxBatchSize int
+
+	mu      sync.Mutex
+	pending []*coalescedCall[T]
+	timer   *time.Timer
+}
+
+// do adds the input to the pending batch, and waits for its result.
+func (c *coalescer[T]) do(ctx context.Context, input string) (T, error) {
+	call := &coalescedCall[T]{ctx: ctx, input: input, done: make(chan struct{})}
+	c.mu.Lock()
+	c.pending = append(c.pending, call)
+	switch {
+	case len(c.pending) >= c.maxBatchSize:
+		calls := c.take()
+		c.mu.Unlock()
+		go c.send(calls)
+	case len(c.pending) == 1:
+		c.timer = time.AfterFunc(c.window, c.flush)
+		c.mu.Unlock()
+	default:
+		c.mu.Unlock()
+	}
+
+	select {
+	case <-call.done:
+		return call.result, call.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// take removes the pending calls. c.mu must be held.
+func (c *coalescer[T]) take() []*coalescedCall[T] {
+	calls := c.pending
+	c.pending = nil
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	return calls
+}
+
+func (c *coalescer[T]) flush() {
+	c.mu.Lock()
+	calls := c.take()
+	c.mu.Unlock()
+	if len(calls) > 0 {
+		c.send(calls)
+	}
+}
+
+// send computes the results of the calls whose callers are still waiting in a
+// single batch. The batch is not bound to any one caller's context, but is
+// cancelled at the latest deadline of its callers, if they all have one.
+func (c *coalescer[T]) send(calls []*coalescedCall[T]) {
+	var live []*coalescedCall[T]
+	var deadline time.Time
+	bounded := true
+	for _, call := range calls {
+		if call.ctx.Err() != nil {
+			continue
+		}
+		live = append(live, call)
+		d, ok := call.ctx.Deadline()
+		bounded = bounded && ok
+		if d.After(deadline) {
+			deadline = d
+		}
+	}
+	if len(live) == 0 {
+		return
+	}
+	ctx := context.Background()
+	if bounded {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+	inputs := make([]string, len(live))
+	for i, call := range live {
+		inputs[i] = call.input
+	}
+	coalescedBatchSize.WithLabelValues(c.op).Observe(float64(len(live)))
+	results, err := c.batch(ctx, inputs)
+	for i, call := range live {
+		if err != nil {
+			call.err = err
+		} else {
+			call.result = results[i]
+		}
+		close(call.done)
+	}
+}
@@ -0,0 +1,248 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingBatch is a batch func that records the inputs of each batch, and
+// returns each input's length.
+type recordingBatch struct {
+	mu      sync.Mutex
+	batches [][]string
+	ctxs    []context.Context
+	err     error
+}
+
+func (r *recordingBatch) batch(ctx context.Context, inputs []string) ([]int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, inputs)
+	r.ctxs = append(r.ctxs, ctx)
+	if r.err != nil {
+		return nil, r.err
+	}
+	results := make([]int, len(inputs))
+	for i, input := range inputs {
+		results[i] = len(input)
+	}
+	return results, nil
+}
+
+func (r *recordingBatch) sizes() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sizes := make([]int, len(r.batches))
+	for i, batch := range r.batches {
+		sizes[i] = len(batch)
+	}
+	return sizes
+}
+
+func newTestCoalescer(r *recordingBatch, window time.Duration, maxBatchSize int) *coalescer[int] {
+	return &coalescer[int]{
+		op:           cacheOpEmbed,
+		batch:        r.batch,
+		window:       window,
+		maxBatchSize: maxBatchSize,
+	}
+}
+
+// doAll calls c.do concurrently with each input, and returns the results and
+// errors in input order.
+func doAll(ctx context.Context, c *coalescer[int], inputs []string) ([]int, []error) {
+	results := make([]int, len(inputs))
+	errs := make([]error, len(inputs))
+	var wg sync.WaitGroup
+	for i, input := range inputs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = c.do(ctx, input)
+		}()
+	}
+	wg.Wait()
+	return results, errs
+}
+
+func TestCoalescerFlushesFullBatch(t *testing.T) {
+	r := &recordingBatch{}
+	// The window is long enough that only a full batch can flush
+	c := newTestCoalescer(r, time.Hour, 3)
+
+	inputs := []string{"a", "bb", "ccc"}
+	results, errs := doAll(context.Background(), c, inputs)
+	for i, input := range inputs {
+		if errs[i] != nil {
+			t.Fatalf("do(%q) failed: %v", input, errs[i])
+		}
+		if results[i] != len(input) {
+			t.Errorf("do(%q) = %d, want %d", input, results[i], len(input))
+		}
+	}
+	if sizes := r.sizes(); len(sizes) != 1 || sizes[0] != 3 {
+		t.Errorf("batch sizes = %v, want [3]", sizes)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.pending) != 0 || c.timer != nil {
+		t.Errorf("coalescer has %d pending calls and timer %v after flush", len(c.pending), c.timer)
+	}
+}
+
+func TestCoalescerFlushesAfterWindow(t *testing.T) {
+	r := &recordingBatch{}
+	c := newTestCoalescer(r, 10*time.Millisecond, 100)
+
+	start := time.Now()
+	results, errs := doAll(context.Background(), c, []string{"a", "bb"})
+	if errs[0] != nil || errs[1] != nil {
+		t.Fatalf("do failed: %v", errs)
+	}
+	if results[0] != 1 || results[1] != 2 {
+		t.Errorf("results = %v, want [1 2]", results)
+	}
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Errorf("batch was sent after %v, before the window", elapsed)
+	}
+	if sizes := r.sizes(); len(sizes) != 1 || sizes[0] != 2 {
+		t.Errorf("batch sizes = %v, want [2]", sizes)
+	}
+}
+
+func TestCoalescerSplitsBatches(t *testing.T) {
+	r := &recordingBatch{}
+	c := newTestCoalescer(r, 10*time.Millisecond, 4)
+
+	inputs := make([]string, 10)
+	for i := range inputs {
+		inputs[i] = fmt.Sprint(i * 100)
+	}
+	results, errs := doAll(context.Background(), c, inputs)
+	for i, input := range inputs {
+		if errs[i] != nil {
+			t.Fatalf("do(%q) failed: %v", input, errs[i])
+		}
+		if results[i] != len(input) {
+			t.Errorf("do(%q) = %d, want %d", input, results[i], len(input))
+		}
+	}
+	var total int
+	for _, size := range r.sizes() {
+		if size > 4 {
+			t.Errorf("batch of %d calls exceeds the maximum batch size", size)
+		}
+		total += size
+	}
+	if total != len(inputs) {
+		t.Errorf("batches hold %d calls, want %d", total, len(inputs))
+	}
+}
+
+func TestCoalescerPropagatesBatchError(t *testing.T) {
+	r := &recordingBatch{err: errors.New("embedding server unavailable")}
+	c := newTestCoalescer(r, time.Hour, 2)
+
+	_, errs := doAll(context.Background(), c, []string{"a", "b"})
+	for i, err := range errs {
+		if !errors.Is(err, r.err) {
+			t.Errorf("call %d failed with %v, want %v", i, err, r.err)
+		}
+	}
+}
+
+func TestCoalescerSkipsCancelledCalls(t *testing.T) {
+	r := &recordingBatch{}
+	c := newTestCoalescer(r, 20*time.Millisecond, 100)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error, 1)
+	go func() {
+		_, err := c.do(ctx, "cancelled")
+		cancelled <- err
+	}()
+	// Wait for the call to be pending before cancelling it
+	for {
+		c.mu.Lock()
+		n := len(c.pending)
+		c.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-cancelled; !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled call failed with %v, want %v", err, context.Canceled)
+	}
+
+	// The live call is sent in the same window, without the cancelled one
+	result, err := c.do(context.Background(), "live")
+	if err != nil {
+		t.Fatalf("live call failed: %v", err)
+	}
+	if result != len("live") {
+		t.Errorf("live call = %d, want %d", result, len("live"))
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.batches) != 1 || len(r.batches[0]) != 1 || r.batches[0][0] != "live" {
+		t.Errorf("batches = %v, want [[live]]", r.batches)
+	}
+}
+
+func TestCoalescerSkipsBatchOfCancelledCalls(t *testing.T) {
+	r := &recordingBatch{}
+	c := newTestCoalescer(r, 5*time.Millisecond, 100)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.do(ctx, "a"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("do failed with %v, want %v", err, context.Canceled)
+	}
+	// Let the window elapse
+	time.Sleep(20 * time.Millisecond)
+	if sizes := r.sizes(); len(sizes) != 0 {
+		t.Errorf("batch sizes = %v, want no batches", sizes)
+	}
+}
+
+func TestCoalescerBatchDeadline(t *testing.T) {
+	r := &recordingBatch{}
+	c := newTestCoalescer(r, time.Hour, 2)
+
+	// The batch is bounded by the latest deadline of its callers
+	early, cancelEarly := context.WithTimeout(context.Background(), time.Minute)
+	defer cancelEarly()
+	late, cancelLate := context.WithTimeout(context.Background(), time.Hour)
+	defer cancelLate()
+	var wg sync.WaitGroup
+	for _, ctx := range []context.Context{early, late} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.do(ctx, "a")
+		}()
+	}
+	wg.Wait()
+	lateDeadline, _ := late.Deadline()
+	r.mu.Lock()
+	deadline, ok := r.ctxs[0].Deadline()
+	r.mu.Unlock()
+	if !ok || !deadline.Equal(lateDeadline) {
+		t.Errorf("batch deadline = %v (%v), want %v", deadline, ok, lateDeadline)
+	}
+
+	// The batch is unbounded if any caller is
+	doAll(context.Background(), c, []string{"a", "b"})
+	r.mu.Lock()
+	_, ok = r.ctxs[1].Deadline()
+	r.mu.Unlock()
+	if ok {
+		t.Error("batch of callers without deadlines has a deadline")
+	}
+}
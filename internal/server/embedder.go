@@ -0,0 +1,65 @@
+package server
+
+import (
+	"context"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Token is the byte offset span of a token within the tokenized input.
+type Token struct {
+	Start int
+	Stop  int
+}
+
+type EmbedderInfo struct {
+	ModelID        string
+	MaxInputLength int
+	MaxBatchSize   int
+}
+
+// Embedder computes embeddings for queries, and tokenizes them so that they
+// can be truncated to the maximum input length of the model.
+type Embedder interface {
+	Info() EmbedderInfo
+	Tokenize(ctx context.Context, input string) ([]Token, error)
+	TokenizeBatch(ctx context.Context, inputs []string) ([][]Token, error)
+	Embed(ctx context.Context, input string) ([]float32, error)
+	EmbedBatch(ctx context.Context, inputs []string) ([][]float32, error)
+	// Check returns an error if the backend is not ready to serve requests.
+	Check(ctx context.Context) error
+}
+
+const approxTokenRunes = 4
+
+// approximateTokens splits the input into pseudo-tokens for backends that do
+// not expose their tokenizer. Runs of letters and digits are split every few
+// runes, and every other non-space rune is a token of its own, which
+// over-estimates the token count of most subword tokenizers.
+func approximateTokens(input string) []Token {
+	var tokens []Token
+	start, runes := -1, 0
+	for i, r := range input {
+		if start >= 0 && (runes == approxTokenRunes || !isWordRune(r)) {
+			tokens = append(tokens, Token{Start: start, Stop: i})
+			start, runes = -1, 0
+		}
+		switch {
+		case isWordRune(r):
+			if start < 0 {
+				start = i
+			}
+			runes++
+		case !unicode.IsSpace(r):
+			tokens = append(tokens, Token{Start: i, Stop: i + utf8.RuneLen(r)})
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, Token{Start: start, Stop: len(input)})
+	}
+	return tokens
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrFilterUnsupported is returned when a request has a filter, but the
+// vector index cannot filter points.
+var ErrFilterUnsupported = errors.New("filters are not supported by the vector index")
+
+// Filter restricts a nearest neighbor search to the points whose payload
+// matches all of the Must conditions, at least one of the Should conditions,
+// and none of the MustNot conditions.
+type Filter struct {
+	Must    []Condition `json:"must,omitempty"`
+	Should  []Condition `json:"should,omitempty"`
+	MustNot []Condition `json:"must_not,omitempty"`
+}
+
+// Condition matches a payload field against a value, or against a range.
+type Condition struct {
+	Key   string `json:"key"`
+	Match *Match `json:"match,omitempty"`
+	Range *Range `json:"range,omitempty"`
+}
+
+// Match matches a field that is equal to Value, or to any of the values of
+// Any. Values are strings, integers or booleans, and the values of Any are
+// either all strings or all integers.
+type Match struct {
+	Value any   `json:"value,omitempty"`
+	Any   []any `json:"any,omitempty"`
+}
+
+// Range matches a numeric field within the given bounds.
+type Range struct {
+	Gt  *float64 `json:"gt,omitempty"`
+	Gte *float64 `json:"gte,omitempty"`
+	Lt  *float64 `json:"lt,omitempty"`
+	Lte *float64 `json:"lte,omitempty"`
+}
+
+func (f *Filter) Validate() error {
+	if len(f.Must)+len(f.Should)+len(f.MustNot) == 0 {
+		return fmt.Errorf("filter has no conditions")
+	}
+	for _, conditions := range [][]Condition{f.Must, f.Should, f.MustNot} {
+		for _, condition := range conditions {
+			if err := condition.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c Condition) Validate() error {
+	if c.Key == "" {
+		return fmt.Errorf("condition key is required")
+	}
+	switch {
+	case c.Match != nil && c.Range != nil:
+		return fmt.Errorf("condition on %s has both match and range", c.Key)
+	case c.Match != nil:
+		if _, err := c.Match.kind(); err != nil {
+			return fmt.Errorf("invalid match on %s: %v", c.Key, err)
+		}
+	case c.Range != nil:
+		if c.Range.Gt == nil && c.Range.Gte == nil && c.Range.Lt == nil && c.Range.Lte == nil {
+			return fmt.Errorf("range on %s has no bounds", c.Key)
+		}
+	default:
+		return fmt.Errorf("condition on %s has neither match nor range", c.Key)
+	}
+	return nil
+}
+
+const (
+	matchKeyword = iota
+	matchInteger
+	matchBoolean
+	matchKeywords
+	matchIntegers
+)
+
+// kind returns which kind of match m is, after checking the types of its
+// values.
+func (m *Match) kind() (int, error) {
+	switch {
+	case m.Value != nil && m.Any != nil:
+		return 0, fmt.Errorf("only one of value and any may be given")
+	case m.Value != nil:
+		switch v := m.Value.(type) {
+		case string:
+			return matchKeyword, nil
+		case bool:
+			return matchBoolean, nil
+		default:
+			if _, ok := integer(v); ok {
+				return matchInteger, nil
+			}
+		}
+		return 0, fmt.Errorf("value must be a string, an integer or a boolean")
+	case len(m.Any) > 0:
+		keywords, integers := 0, 0
+		for _, v := range m.Any {
+			if _, ok := v.(string); ok {
+				keywords++
+			} else if _, ok := integer(v); ok {
+				integers++
+			}
+		}
+		if keywords == len(m.Any) {
+			return matchKeywords, nil
+		}
+		if integers == len(m.Any) {
+			return matchIntegers, nil
+		}
+		return 0, fmt.Errorf("any must be all strings or all integers")
+	}
+	return 0, fmt.Errorf("value or any is required")
+}
+
+// integer returns v as an integer, if it is a whole number. Numbers decoded
+// from JSON are float64s.
+func integer(v any) (int64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return v, true
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<63 {
+			return int64(v), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestFilterValidate(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		filter  string
+		wantErr bool
+	}{
+		{name: "match value", filter: `{"must":[{"key":"lang","match":{"value":"en"}}]}`},
+		{name: "match integer", filter: `{"should":[{"key":"tier","match":{"value":2}}]}`},
+		{name: "match boolean", filter: `{"must_not":[{"key":"beta","match":{"value":true}}]}`},
+		{name: "match any", filter: `{"must":[{"key":"lang","match":{"any":["en","fr"]}}]}`},
+		{name: "range", filter: `{"must":[{"key":"length","range":{"gte":10,"lt":100}}]}`},
+		{name: "no conditions", filter: `{}`, wantErr: true},
+		{name: "missing key", filter: `{"must":[{"match":{"value":"en"}}]}`, wantErr: true},
+		{name: "unsupported operator", filter: `{"must":[{"key":"lang","regex":"e.*"}]}`, wantErr: true},
+		{name: "match and range", filter: `{"must":[{"key":"tier","match":{"value":2},"range":{"gt":1}}]}`, wantErr: true},
+		{name: "range without bounds", filter: `{"must":[{"key":"length","range":{}}]}`, wantErr: true},
+		{name: "empty match", filter: `{"must":[{"key":"lang","match":{}}]}`, wantErr: true},
+		{name: "value and any", filter: `{"must":[{"key":"lang","match":{"value":"en","any":["fr"]}}]}`, wantErr: true},
+		{name: "float value", filter: `{"must":[{"key":"score","match":{"value":0.5}}]}`, wantErr: true},
+		{name: "object value", filter: `{"must":[{"key":"meta","match":{"value":{"a":1}}}]}`, wantErr: true},
+		{name: "mixed any", filter: `{"must":[{"key":"tier","match":{"any":["en",2]}}]}`, wantErr: true},
+		{name: "invalid nested condition", filter: `{"must":[{"key":"lang","match":{"value":"en"}}],"must_not":[{"key":""}]}`, wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var filter Filter
+			if err := json.Unmarshal([]byte(tt.filter), &filter); err != nil {
+				t.Fatalf("failed to decode filter: %v", err)
+			}
+			if err := filter.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,256 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+
+	"github.com/pulzeai-oss/knn-router/internal/routerpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+type routerServer struct {
+	routerpb.UnimplementedRouterServiceServer
+	s *Server
+}
+
+func (rs *routerServer) Route(
+	ctx context.Context,
+	req *routerpb.RouteRequest,
+) (*routerpb.RouteResponse, error) {
+	router, ok := rs.s.router(req.GetRouter())
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown router: %s", req.GetRouter())
+	}
+	payload := requestFromProto(req)
+	applyKeyDefaults(ctx, &payload)
+	if err := router.validate(&payload); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	res, err := router.query(ctx, &payload)
+	if errors.Is(err, ErrNoTargets) || errors.Is(err, ErrFilterUnsupported) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to retrieve scores: %v", err)
+	}
+	return res.toProto(), nil
+}
+
+func (rs *routerServer) RouteBatch(
+	ctx context.Context,
+	req *routerpb.RouteBatchRequest,
+) (*routerpb.RouteBatchResponse, error) {
+	if len(req.GetRequests()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "requests are required")
+	}
+	router, ok := rs.s.router(req.GetRouter())
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown router: %s", req.GetRouter())
+	}
+	if retryAfter, err := chargeQueries(ctx, len(req.GetRequests())); err != nil {
+		if status.Code(err) == codes.ResourceExhausted {
+			grpc.SetHeader(ctx, metadata.Pairs("retry-after", retryAfterSeconds(retryAfter)))
+		}
+		return nil, err
+	}
+	payloads := make([]Request, len(req.GetRequests()))
+	for i, r := range req.GetRequests() {
+		payloads[i] = requestFromProto(r)
+		applyKeyDefaults(ctx, &payloads[i])
+	}
+
+	out := &routerpb.RouteBatchResponse{
+		Results: make([]*routerpb.RouteBatchResult, len(payloads)),
+	}
+	for i, res := range router.queryBatch(ctx, payloads) {
+		if res.Error != "" {
+			out.Results[i] = &routerpb.RouteBatchResult{
+				Result: &routerpb.RouteBatchResult_Error{Error: res.Error},
+			}
+			continue
+		}
+		out.Results[i] = &routerpb.RouteBatchResult{
+			Result: &routerpb.RouteBatchResult_Response{Response: res.toProto()},
+		}
+	}
+	return out, nil
+}
+
+func requestFromProto(req *routerpb.RouteRequest) Request {
+	payload := Request{
+		Query:            req.GetQuery(),
+		TruncateStrategy: TruncateStrategy(req.GetTruncateStrategy()),
+	}
+	if payload.TruncateStrategy == 0 {
+		payload.TruncateStrategy = Middle
+	}
+	if req.TopK != nil {
+		topK := int(req.GetTopK())
+		payload.TopK = &topK
+	}
+	payload.MinSimilarity = req.MinSimilarity
+	payload.AllowedTargets = req.GetAllowedTargets()
+	payload.ExcludedTargets = req.GetExcludedTargets()
+	payload.NoCache = req.GetNoCache()
+	for _, m := range req.GetMessages() {
+		content, _ := json.Marshal(m.GetContent())
+		payload.Messages = append(payload.Messages, ChatMessage{Role: m.GetRole(), Content: content})
+	}
+	if t := req.GetTurnSelection(); t != nil {
+		payload.TurnSelection = &TurnSelection{
+			Strategy: turnStrategies[t.GetStrategy()],
+			Turns:    int(t.GetTurns()),
+		}
+	}
+	if f := req.GetFilter(); f != nil {
+		payload.Filter = &Filter{
+			Must:    conditionsFromProto(f.GetMust()),
+			Should:  conditionsFromProto(f.GetShould()),
+			MustNot: conditionsFromProto(f.GetMustNot()),
+		}
+	}
+	if w := req.GetWeighting(); w != nil {
+		payload.Weighting = &Weighting{
+			Kernel:      weightingKernels[w.GetKernel()],
+			Temperature: w.GetTemperature(),
+			Decay:       w.GetDecay(),
+			Exponent:    w.GetExponent(),
+		}
+	}
+	return payload
+}
+
+func conditionsFromProto(conditions []*routerpb.Condition) []Condition {
+	out := make([]Condition, len(conditions))
+	for i, c := range conditions {
+		out[i].Key = c.GetKey()
+		if m := c.GetMatch(); m != nil {
+			out[i].Match = &Match{Value: matchValueFromProto(m.GetValue())}
+			for _, v := range m.GetAny() {
+				out[i].Match.Any = append(out[i].Match.Any, matchValueFromProto(v))
+			}
+		}
+		if r := c.GetRange(); r != nil {
+			out[i].Range = &Range{Gt: r.Gt, Gte: r.Gte, Lt: r.Lt, Lte: r.Lte}
+		}
+	}
+	return out
+}
+
+func matchValueFromProto(v *routerpb.MatchValue) any {
+	switch kind := v.GetKind().(type) {
+	case *routerpb.MatchValue_Keyword:
+		return kind.Keyword
+	case *routerpb.MatchValue_Integer:
+		return kind.Integer
+	case *routerpb.MatchValue_Boolean:
+		return kind.Boolean
+	}
+	return nil
+}
+
+var weightingKernels = map[routerpb.WeightingKernel]WeightingKernel{
+	routerpb.WeightingKernel_WEIGHTING_KERNEL_SIMILARITY: SimilarityKernel,
+	routerpb.WeightingKernel_WEIGHTING_KERNEL_SOFTMAX:    SoftmaxKernel,
+	routerpb.WeightingKernel_WEIGHTING_KERNEL_RANK:       RankKernel,
+	routerpb.WeightingKernel_WEIGHTING_KERNEL_UNIFORM:    UniformKernel,
+	routerpb.WeightingKernel_WEIGHTING_KERNEL_POWER:      PowerKernel,
+}
+
+var turnStrategies = map[routerpb.TurnStrategy]TurnStrategy{
+	routerpb.TurnStrategy_TURN_STRATEGY_LAST_USER:        LastUserStrategy,
+	routerpb.TurnStrategy_TURN_STRATEGY_LAST_N:           LastNStrategy,
+	routerpb.TurnStrategy_TURN_STRATEGY_SYSTEM_LAST_USER: SystemLastUserStrategy,
+	routerpb.TurnStrategy_TURN_STRATEGY_PER_TURN:         PerTurnStrategy,
+}
+
+func (w Weighting) toProto() *routerpb.Weighting {
+	out := &routerpb.Weighting{
+		Temperature: w.Temperature,
+		Decay:       w.Decay,
+		Exponent:    w.Exponent,
+	}
+	for kernel, name := range weightingKernels {
+		if name == w.Kernel {
+			out.Kernel = kernel
+		}
+	}
+	return out
+}
+
+func (res *Response) toProto() *routerpb.RouteResponse {
+	out := &routerpb.RouteResponse{
+		Hits:              make([]*routerpb.Hit, 0, len(res.Hits)),
+		Weighting:         res.Weighting.toProto(),
+		OutOfDistribution: res.OutOfDistribution,
+		TopK:              int32(res.TopK),
+		MinSimilarity:     res.MinSimilarity,
+	}
+	for _, hit := range res.Hits {
+		out.Hits = append(
+			out.Hits,
+			&routerpb.Hit{
+				Id:         hit.ID,
+				Category:   hit.Category,
+				Similarity: hit.Similarity,
+				Weight:     hit.Weight,
+			},
+		)
+	}
+	out.Scores = scoresToProto(res.Scores)
+	if d := res.Decision; d != nil {
+		out.Decision = &routerpb.Decision{
+			Target:     d.Target,
+			Score:      d.Score,
+			Margin:     d.Margin,
+			Alternates: scoresToProto(d.Alternates),
+		}
+	}
+	return out
+}
+
+func scoresToProto(scores []Score) []*routerpb.Score {
+	out := make([]*routerpb.Score, 0, len(scores))
+	for _, score := range scores {
+		out = append(out, &routerpb.Score{Target: score.Target, Score: score.Score})
+	}
+	return out
+}
+
+// ServeGRPC serves the RouterService, along with the standard gRPC health and
+// reflection services, on the given address until the server is shut down.
+func (s *Server) ServeGRPC(bindAddr string) error {
+	lis, err := net.Listen("tcp", bindAddr)
+	if err != nil {
+		return err
+	}
+
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		instrumentUnaryServer,
+		s.auth.unaryInterceptor(routerpb.RouterService_ServiceDesc.ServiceName),
+	))
+	routerpb.RegisterRouterServiceServer(grpcServer, &routerServer{s: s})
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus(
+		routerpb.RouterService_ServiceDesc.ServiceName,
+		healthpb.HealthCheckResponse_SERVING,
+	)
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	reflection.Register(grpcServer)
+
+	s.serveMu.Lock()
+	s.grpcServer, s.healthServer = grpcServer, healthServer
+	s.serveMu.Unlock()
+	return grpcServer.Serve(lis)
+}
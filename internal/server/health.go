@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const readinessTimeout = 5 * time.Second
+
+type CheckResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type ReadinessResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+func (s *Server) healthzHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	fmt.Fprintln(w, "ok")
+}
+
+func (s *Server) readyzHandler(w http.ResponseWriter, r *http.Request) {
+	res := &ReadinessResponse{Status: "draining"}
+	if !s.draining.Load() {
+		res = s.checkReadiness(r.Context())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if res.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// checkReadiness concurrently checks the embedder, the vector index and the
+// scores database of every router, and reports the result of each check.
+// The checks of named routers are prefixed with the router name.
+func (s *Server) checkReadiness(ctx context.Context) *ReadinessResponse {
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	checks := make(map[string]func() error)
+	defer s.readinessChecks(ctx, "", checks)()
+	for name, router := range s.routers {
+		defer router.readinessChecks(ctx, name+"/", checks)()
+	}
+
+	res := ReadinessResponse{Status: "ok", Checks: make(map[string]CheckResult)}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := CheckResult{Status: "ok"}
+			if err := check(); err != nil {
+				result = CheckResult{Status: "error", Error: err.Error()}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			res.Checks[name] = result
+			if result.Status != "ok" {
+				res.Status = "unavailable"
+			}
+		}()
+	}
+	wg.Wait()
+	return &res
+}
+
+// readinessChecks adds the checks of the router to checks, with their names
+// prefixed. The returned func releases the dataset being checked.
+func (s *Server) readinessChecks(ctx context.Context, prefix string, checks map[string]func() error) func() {
+	d, release := s.acquire()
+
+	// The number of points in the vector index must match the scores database
+	var numPoints uint64
+	dbErr := d.DB.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(d.collection))
+		if b == nil {
+			return fmt.Errorf("could not find bucket %s", d.collection)
+		}
+		numPoints = uint64(b.Stats().KeyN)
+		return nil
+	})
+
+	checks[prefix+"db"] = func() error { return dbErr }
+	checks[prefix+"embedder"] = func() error {
+		return s.embedder.Check(ctx)
+	}
+	checks[prefix+"index"] = func() error {
+		count, err := d.index.Count(ctx)
+		if err != nil {
+			return err
+		}
+		if dbErr == nil && count != numPoints {
+			return fmt.Errorf("index has %d points, expected %d", count, numPoints)
+		}
+		return nil
+	}
+	return release
+}
@@ -0,0 +1,23 @@
+package server
+
+import "context"
+
+// Neighbor is a point returned by a nearest neighbor search.
+type Neighbor struct {
+	ID         string
+	Similarity float32
+}
+
+// VectorIndex searches for the nearest neighbors of query embeddings,
+// optionally among the points matching a filter. Indexes that cannot filter
+// points return ErrFilterUnsupported.
+type VectorIndex interface {
+	Search(ctx context.Context, vector []float32, limit int, filter *Filter) ([]Neighbor, error)
+	// SearchBatch searches for the nearest neighbors of each vector, among
+	// the points matching the filter of the same index.
+	SearchBatch(ctx context.Context, vectors [][]float32, limit int, filters []*Filter) ([][]Neighbor, error)
+	// Count returns the number of points in the index.
+	Count(ctx context.Context) (uint64, error)
+	// UIDs returns the UIDs of all points in the index.
+	UIDs(ctx context.Context) ([]string, error)
+}
@@ -0,0 +1,181 @@
+package server
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/pulzeai-oss/knn-router/internal/store"
+	bolt "go.etcd.io/bbolt"
+)
+
+// LocalIndex is an in-process VectorIndex that performs an exact cosine
+// similarity search over the embeddings in a vectors bucket of the scores
+// database. It does not support filters.
+type LocalIndex struct {
+	ids     []string
+	vectors []float32
+	dim     int
+}
+
+// NewLocalIndex loads the embeddings of the points in the given vector
+// collection.
+func NewLocalIndex(DB *bolt.DB, collection string) (*LocalIndex, error) {
+	var idx LocalIndex
+	bucket := store.VectorsBucket(collection)
+	err := DB.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return fmt.Errorf("could not find bucket %s", bucket)
+		}
+		return b.ForEach(func(k, v []byte) error {
+			vector, err := store.DecodeVector(v)
+			if err != nil {
+				return fmt.Errorf("failed to decode vector for UID %s: %v", k, err)
+			}
+			return idx.add(string(k), vector)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &idx, nil
+}
+
+// NewLocalIndexFromVectors builds a LocalIndex from in-memory embeddings,
+// keyed by UID.
+func NewLocalIndexFromVectors(vectors map[string][]float32) (*LocalIndex, error) {
+	uids := make([]string, 0, len(vectors))
+	for uid := range vectors {
+		uids = append(uids, uid)
+	}
+	slices.Sort(uids)
+
+	var idx LocalIndex
+	for _, uid := range uids {
+		vector := make([]float32, len(vectors[uid]))
+		copy(vector, vectors[uid])
+		if err := idx.add(uid, vector); err != nil {
+			return nil, err
+		}
+	}
+	return &idx, nil
+}
+
+// add normalizes the vector in place, and adds it to the index.
+func (idx *LocalIndex) add(uid string, vector []float32) error {
+	if idx.dim == 0 {
+		idx.dim = len(vector)
+	}
+	if len(vector) != idx.dim {
+		return fmt.Errorf(
+			"vector for UID %s has dimension %d, expected %d",
+			uid,
+			len(vector),
+			idx.dim,
+		)
+	}
+	normalize(vector)
+	idx.ids = append(idx.ids, uid)
+	idx.vectors = append(idx.vectors, vector...)
+	return nil
+}
+
+func (idx *LocalIndex) Search(
+	_ context.Context,
+	vector []float32,
+	limit int,
+	filter *Filter,
+) ([]Neighbor, error) {
+	if filter != nil {
+		return nil, ErrFilterUnsupported
+	}
+	if len(vector) != idx.dim {
+		return nil, fmt.Errorf(
+			"query vector has dimension %d, expected %d",
+			len(vector),
+			idx.dim,
+		)
+	}
+	if limit <= 0 {
+		return nil, nil
+	}
+	query := make([]float32, len(vector))
+	copy(query, vector)
+	normalize(query)
+
+	// Keep the top hits in a min-heap, so that the worst hit is evicted first
+	h := make(neighborHeap, 0, min(limit, len(idx.ids))+1)
+	for i, id := range idx.ids {
+		var similarity float32
+		for j, x := range idx.vectors[i*idx.dim : (i+1)*idx.dim] {
+			similarity += x * query[j]
+		}
+		if len(h) == limit && similarity <= h[0].Similarity {
+			continue
+		}
+		heap.Push(&h, Neighbor{ID: id, Similarity: similarity})
+		if len(h) > limit {
+			heap.Pop(&h)
+		}
+	}
+	neighbors := make([]Neighbor, len(h))
+	for i := len(h) - 1; i >= 0; i-- {
+		neighbors[i] = heap.Pop(&h).(Neighbor)
+	}
+	return neighbors, nil
+}
+
+func (idx *LocalIndex) SearchBatch(
+	ctx context.Context,
+	vectors [][]float32,
+	limit int,
+	filters []*Filter,
+) ([][]Neighbor, error) {
+	neighbors := make([][]Neighbor, len(vectors))
+	for i, vector := range vectors {
+		var err error
+		neighbors[i], err = idx.Search(ctx, vector, limit, filters[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return neighbors, nil
+}
+
+func (idx *LocalIndex) Count(context.Context) (uint64, error) {
+	return uint64(len(idx.ids)), nil
+}
+
+func (idx *LocalIndex) UIDs(context.Context) ([]string, error) {
+	return slices.Clone(idx.ids), nil
+}
+
+func normalize(vector []float32) {
+	var norm float64
+	for _, x := range vector {
+		norm += float64(x) * float64(x)
+	}
+	if norm == 0 {
+		return
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for i := range vector {
+		vector[i] *= scale
+	}
+}
+
+type neighborHeap []Neighbor
+
+func (h neighborHeap) Len() int           { return len(h) }
+func (h neighborHeap) Less(i, j int) bool { return h[i].Similarity < h[j].Similarity }
+func (h neighborHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *neighborHeap) Push(x any)        { *h = append(*h, x.(Neighbor)) }
+func (h *neighborHeap) Pop() any {
+	old := *h
+	n := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return n
+}
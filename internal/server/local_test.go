@@ -0,0 +1,51 @@
+package server
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestLocalIndexSearch(t *testing.T) {
+	idx, err := NewLocalIndexFromVectors(map[string][]float32{
+		"a": {1, 0},
+		"b": {1, 1},
+		"c": {0, 1},
+		"d": {-1, 0},
+	})
+	if err != nil {
+		t.Fatalf("failed to build index: %v", err)
+	}
+	for _, tt := range []struct {
+		name  string
+		limit int
+		want  []string
+	}{
+		{name: "zero limit", limit: 0},
+		{name: "nearest", limit: 1, want: []string{"a"}},
+		{name: "limit", limit: 3, want: []string{"a", "b", "c"}},
+		{name: "limit larger than the points", limit: 1000, want: []string{"a", "b", "c", "d"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// The query is not normalized, and is closest to a, then b
+			neighbors, err := idx.Search(context.Background(), []float32{2, 0.5}, tt.limit, nil)
+			if err != nil {
+				t.Fatalf("Search failed: %v", err)
+			}
+			var ids []string
+			for i, neighbor := range neighbors {
+				ids = append(ids, neighbor.ID)
+				if i > 0 && neighbor.Similarity > neighbors[i-1].Similarity {
+					t.Errorf("neighbors are not ordered by decreasing similarity: %v", neighbors)
+				}
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("Search() = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+
+	if _, err := idx.Search(context.Background(), []float32{1, 0, 0}, 1, nil); err == nil {
+		t.Error("Search succeeded with a query vector of the wrong dimension")
+	}
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// maxTurns is the largest number of turns a request may ask to embed.
+const maxTurns = 64
+
+type TurnStrategy string
+
+const (
+	// LastUserStrategy embeds the last user turn.
+	LastUserStrategy TurnStrategy = "last_user"
+	// LastNStrategy embeds the last n user and assistant turns, joined.
+	LastNStrategy TurnStrategy = "last_n"
+	// SystemLastUserStrategy embeds the system prompt and the last user turn,
+	// joined.
+	SystemLastUserStrategy TurnStrategy = "system_last_user"
+	// PerTurnStrategy embeds each of the last n user and assistant turns
+	// separately, and averages the embeddings.
+	PerTurnStrategy TurnStrategy = "per_turn"
+)
+
+// ChatMessage is a message of an OpenAI chat completion request.
+type ChatMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+// Text returns the text content of the message, joining the text parts of
+// multi-part content.
+func (m ChatMessage) Text() string {
+	var text string
+	if err := json.Unmarshal(m.Content, &text); err == nil {
+		return text
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(m.Content, &parts); err != nil {
+		return ""
+	}
+	var texts []string
+	for _, part := range parts {
+		if part.Type == "text" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// TurnSelection configures which turns of a conversation are embedded. Turns
+// is only used by the last_n and per_turn strategies.
+type TurnSelection struct {
+	Strategy TurnStrategy `json:"strategy,omitempty"`
+	Turns    int          `json:"turns,omitempty"`
+}
+
+// Override returns the turn selection with the non-zero fields of o applied.
+func (t TurnSelection) Override(o *TurnSelection) TurnSelection {
+	if o != nil {
+		if o.Strategy != "" {
+			t.Strategy = o.Strategy
+		}
+		if o.Turns != 0 {
+			t.Turns = o.Turns
+		}
+	}
+	return t
+}
+
+func (t TurnSelection) Validate() error {
+	switch t.Strategy {
+	case LastUserStrategy, SystemLastUserStrategy:
+	case LastNStrategy, PerTurnStrategy:
+		if t.Turns < 1 || t.Turns > maxTurns {
+			return fmt.Errorf("turns must be between 1 and %d", maxTurns)
+		}
+	default:
+		return fmt.Errorf("unsupported turn strategy: %s", t.Strategy)
+	}
+	return nil
+}
+
+// Texts returns the texts to embed for the given messages. Every strategy
+// returns a single text, except per_turn, which returns one per turn.
+func (t TurnSelection) Texts(messages []ChatMessage) ([]string, error) {
+	var system, turns []string
+	lastUser := -1
+	for _, message := range messages {
+		text := message.Text()
+		if text == "" {
+			continue
+		}
+		switch message.Role {
+		case "system", "developer":
+			system = append(system, text)
+		case "user":
+			lastUser = len(turns)
+			turns = append(turns, text)
+		case "assistant":
+			turns = append(turns, text)
+		}
+	}
+	if lastUser < 0 {
+		return nil, fmt.Errorf("messages have no user turn")
+	}
+
+	switch t.Strategy {
+	case LastUserStrategy:
+		return []string{turns[lastUser]}, nil
+	case SystemLastUserStrategy:
+		return []string{strings.Join(append(system, turns[lastUser]), "\n")}, nil
+	case LastNStrategy:
+		return []string{strings.Join(turns[max(len(turns)-t.Turns, 0):], "\n")}, nil
+	case PerTurnStrategy:
+		return turns[max(len(turns)-t.Turns, 0):], nil
+	}
+	return nil, fmt.Errorf("unsupported turn strategy: %s", t.Strategy)
+}
+
+// meanEmbedding averages the given embeddings, after normalizing each of them
+// so that every turn contributes equally.
+func meanEmbedding(vectors [][]float32) []float32 {
+	if len(vectors) == 1 {
+		return vectors[0]
+	}
+	mean := make([]float32, len(vectors[0]))
+	for _, vector := range vectors {
+		vector = append([]float32(nil), vector...)
+		normalize(vector)
+		for i, x := range vector {
+			mean[i] += x / float32(len(vectors))
+		}
+	}
+	return mean
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func message(role, text string) ChatMessage {
+	content, _ := json.Marshal(text)
+	return ChatMessage{Role: role, Content: content}
+}
+
+func TestTurnSelectionTexts(t *testing.T) {
+	conversation := []ChatMessage{
+		message("system", "be brief"),
+		message("user", "hi"),
+		message("assistant", "hello"),
+		message("tool", "42"),
+		message("user", "what is 6 times 7?"),
+		message("assistant", ""),
+		{Role: "developer", Content: json.RawMessage(`[{"type":"text","text":"answer in French"},{"type":"image_url"}]`)},
+	}
+	for _, tt := range []struct {
+		name      string
+		selection TurnSelection
+		messages  []ChatMessage
+		want      []string
+		wantErr   bool
+	}{
+		{
+			name:      "last user turn",
+			selection: TurnSelection{Strategy: LastUserStrategy},
+			messages:  conversation,
+			want:      []string{"what is 6 times 7?"},
+		},
+		{
+			name:      "system and last user turn",
+			selection: TurnSelection{Strategy: SystemLastUserStrategy},
+			messages:  conversation,
+			want:      []string{"be brief\nanswer in French\nwhat is 6 times 7?"},
+		},
+		{
+			name:      "last n turns",
+			selection: TurnSelection{Strategy: LastNStrategy, Turns: 2},
+			messages:  conversation,
+			want:      []string{"hello\nwhat is 6 times 7?"},
+		},
+		{
+			name:      "all turns",
+			selection: TurnSelection{Strategy: LastNStrategy, Turns: maxTurns},
+			messages:  conversation,
+			want:      []string{"hi\nhello\nwhat is 6 times 7?"},
+		},
+		{
+			name:      "per turn",
+			selection: TurnSelection{Strategy: PerTurnStrategy, Turns: 2},
+			messages:  conversation,
+			want:      []string{"hello", "what is 6 times 7?"},
+		},
+		{
+			name:      "last user turn before assistant turns",
+			selection: TurnSelection{Strategy: LastUserStrategy},
+			messages:  []ChatMessage{message("user", "hi"), message("assistant", "hello")},
+			want:      []string{"hi"},
+		},
+		{
+			name:      "no user turn",
+			selection: TurnSelection{Strategy: LastNStrategy, Turns: 2},
+			messages:  []ChatMessage{message("system", "be brief"), message("assistant", "hello")},
+			wantErr:   true,
+		},
+		{
+			name:      "empty transcript",
+			selection: TurnSelection{Strategy: LastUserStrategy},
+			wantErr:   true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.selection.Texts(tt.messages)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Texts() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Texts() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const metricsNamespace = "knn_router"
+
+var (
+	requestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "requests_total",
+		Help:      "Number of routing requests, by endpoint and status.",
+	}, []string{"endpoint", "status"})
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "request_duration_seconds",
+		Help:      "Latency of routing requests, by endpoint.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"endpoint"})
+	stageDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "stage_duration_seconds",
+		Help:      "Latency of each stage of a routing request.",
+		Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 14),
+	}, []string{"stage"})
+	truncationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "truncations_total",
+		Help:      "Number of queries truncated to the maximum input length, by strategy.",
+	}, []string{"strategy"})
+	topSimilarity = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "top_similarity",
+		Help:      "Similarity of the nearest neighbor of each query.",
+		Buckets:   prometheus.LinearBuckets(0, 0.05, 21),
+	})
+	outOfDistributionTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "out_of_distribution_total",
+		Help:      "Number of queries with too few similar neighbors, for which fallback scores were returned.",
+	})
+	targetWinsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "target_wins_total",
+		Help:      "Number of queries for which each target had the highest score.",
+	}, []string{"target"})
+	upstreamErrorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "upstream_errors_total",
+		Help:      "Number of failed gRPC calls to upstream services, by upstream, method and code.",
+	}, []string{"upstream", "method", "code"})
+	embedCacheRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "embed_cache_requests_total",
+		Help:      "Number of embedding cache lookups, by operation and result (hit, miss, bypass).",
+	}, []string{"op", "result"})
+	embedCacheEvictionsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "embed_cache_evictions_total",
+		Help:      "Number of entries evicted from the embedding cache to stay within its size limits.",
+	})
+	embedCacheBytes = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "embed_cache_bytes",
+		Help:      "Approximate size of the embedding cache in bytes.",
+	})
+	coalescedBatchSize = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "coalesced_batch_size",
+		Help:      "Number of concurrent calls sent to the embedding server in a single batch, by operation.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 8),
+	}, []string{"op"})
+	upstreamRetriesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "upstream_retries_total",
+		Help:      "Number of retried gRPC calls to upstream services, by upstream and method.",
+	}, []string{"upstream", "method"})
+	breakerState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "circuit_breaker_state",
+		Help:      "State of the circuit breaker of each upstream service (0 closed, 1 open, 2 half-open).",
+	}, []string{"upstream"})
+	reloadsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "reloads_total",
+		Help:      "Number of scores database reloads, by result.",
+	}, []string{"result"})
+	clientRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "client_requests_total",
+		Help:      "Number of authenticated requests, by API key name and result (ok, rate_limited).",
+	}, []string{"key", "result"})
+	authFailuresTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "auth_failures_total",
+		Help:      "Number of requests rejected for a missing or invalid API key.",
+	})
+)
+
+const (
+	stageTokenize    = "tokenize"
+	stageEmbed       = "embed"
+	stageSearch      = "search"
+	stageScoreLookup = "score_lookup"
+)
+
+func observeStage(stage string, start time.Time) {
+	stageDuration.WithLabelValues(stage).Observe(time.Since(start).Seconds())
+}
+
+// observeResponse records the winning target of a response, or whether it
+// was out of distribution.
+func observeResponse(res *Response) {
+	if res.OutOfDistribution {
+		outOfDistributionTotal.Inc()
+		return
+	}
+	if res.Decision != nil {
+		targetWinsTotal.WithLabelValues(res.Decision.Target).Inc()
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap allows http.ResponseController to flush the underlying writer.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// instrumentHandler records the status and latency of requests to an HTTP
+// endpoint.
+func instrumentHandler(endpoint string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler(rec, r)
+		requestDuration.WithLabelValues(endpoint).Observe(time.Since(start).Seconds())
+		requestsTotal.WithLabelValues(endpoint, strconv.Itoa(rec.status)).Inc()
+	}
+}
+
+// instrumentUnaryServer records the status and latency of requests to the
+// gRPC server.
+func instrumentUnaryServer(
+	ctx context.Context,
+	req any,
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	requestDuration.WithLabelValues(info.FullMethod).Observe(time.Since(start).Seconds())
+	requestsTotal.WithLabelValues(info.FullMethod, status.Code(err).String()).Inc()
+	return resp, err
+}
+
+// UpstreamDialOptions returns dial options that apply the policy to calls to
+// the named upstream service, and count failed calls.
+func UpstreamDialOptions(upstream string, policy UpstreamPolicy) []grpc.DialOption {
+	countError := func(method string, err error) {
+		if err != nil && !errors.Is(err, io.EOF) {
+			upstreamErrorsTotal.WithLabelValues(upstream, method, status.Code(err).String()).Inc()
+		}
+	}
+	// Count each attempt of retried calls
+	return append(
+		resilienceDialOptions(upstream, policy),
+		grpc.WithChainUnaryInterceptor(func(
+			ctx context.Context,
+			method string,
+			req, reply any,
+			cc *grpc.ClientConn,
+			invoker grpc.UnaryInvoker,
+			opts ...grpc.CallOption,
+		) error {
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			countError(method, err)
+			return err
+		}),
+		grpc.WithChainStreamInterceptor(func(
+			ctx context.Context,
+			desc *grpc.StreamDesc,
+			cc *grpc.ClientConn,
+			method string,
+			streamer grpc.Streamer,
+			opts ...grpc.CallOption,
+		) (grpc.ClientStream, error) {
+			stream, err := streamer(ctx, desc, cc, method, opts...)
+			countError(method, err)
+			if err != nil {
+				return nil, err
+			}
+			return &countingStream{ClientStream: stream, countError: func(err error) {
+				countError(method, err)
+			}}, nil
+		}),
+	)
+}
+
+// countingStream counts the first error of a stream, since a broken stream
+// fails every later send and receive too.
+type countingStream struct {
+	grpc.ClientStream
+	countError func(error)
+	once       sync.Once
+}
+
+func (s *countingStream) count(err error) {
+	if err != nil && !errors.Is(err, io.EOF) {
+		s.once.Do(func() { s.countError(err) })
+	}
+}
+
+func (s *countingStream) SendMsg(m any) error {
+	err := s.ClientStream.SendMsg(m)
+	s.count(err)
+	return err
+}
+
+func (s *countingStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	s.count(err)
+	return err
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// openAICheckTTL is how long a successful check of an OpenAI-compatible API is
+// reused, so that readiness probes do not each pay for an embedding.
+const openAICheckTTL = time.Minute
+
+// OpenAIEmbedder is an Embedder backed by an OpenAI-compatible `/embeddings`
+// HTTP API. Such APIs do not expose their tokenizer, so queries are tokenized
+// approximately for truncation.
+type OpenAIEmbedder struct {
+	client  *http.Client
+	baseURL string
+	model   string
+	apiKey  string
+	info    EmbedderInfo
+
+	checkMu   sync.Mutex
+	checkedAt time.Time
+}
+
+type openAIEmbeddingsRequest struct {
+	Input          []string `json:"input"`
+	Model          string   `json:"model"`
+	EncodingFormat string   `json:"encoding_format"`
+}
+
+type openAIEmbeddingsResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+func NewOpenAIEmbedder(
+	baseURL string,
+	model string,
+	apiKey string,
+	maxInputLength int,
+	maxBatchSize int,
+	tlsConfig *tls.Config,
+) *OpenAIEmbedder {
+	// A nil TLS config keeps the system defaults
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+	return &OpenAIEmbedder{
+		client:  &http.Client{Transport: transport},
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		model:   model,
+		apiKey:  apiKey,
+		info: EmbedderInfo{
+			ModelID:        model,
+			MaxInputLength: maxInputLength,
+			MaxBatchSize:   maxBatchSize,
+		},
+	}
+}
+
+func (e *OpenAIEmbedder) Info() EmbedderInfo {
+	return e.info
+}
+
+// Check embeds a short probe, as there is no standard health endpoint for
+// OpenAI-compatible APIs. A successful check is reused for openAICheckTTL.
+func (e *OpenAIEmbedder) Check(ctx context.Context) error {
+	e.checkMu.Lock()
+	defer e.checkMu.Unlock()
+	if time.Since(e.checkedAt) < openAICheckTTL {
+		return nil
+	}
+	if _, err := e.Embed(ctx, "ping"); err != nil {
+		return err
+	}
+	e.checkedAt = time.Now()
+	return nil
+}
+
+func (e *OpenAIEmbedder) Tokenize(_ context.Context, input string) ([]Token, error) {
+	return approximateTokens(input), nil
+}
+
+func (e *OpenAIEmbedder) TokenizeBatch(_ context.Context, inputs []string) ([][]Token, error) {
+	tokens := make([][]Token, len(inputs))
+	for i, input := range inputs {
+		tokens[i] = approximateTokens(input)
+	}
+	return tokens, nil
+}
+
+func (e *OpenAIEmbedder) Embed(ctx context.Context, input string) ([]float32, error) {
+	vectors, err := e.EmbedBatch(ctx, []string{input})
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+func (e *OpenAIEmbedder) EmbedBatch(ctx context.Context, inputs []string) ([][]float32, error) {
+	body, err := json.Marshal(openAIEmbeddingsRequest{
+		Input:          inputs,
+		Model:          e.model,
+		EncodingFormat: "float",
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		e.baseURL+"/embeddings",
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("embeddings request failed with status %d: %s", resp.StatusCode, msg)
+	}
+
+	var embeddingsResp openAIEmbeddingsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embeddingsResp); err != nil {
+		return nil, fmt.Errorf("failed to decode embeddings response: %v", err)
+	}
+	vectors := make([][]float32, len(inputs))
+	for _, data := range embeddingsResp.Data {
+		if data.Index < 0 || data.Index >= len(inputs) {
+			return nil, fmt.Errorf("embeddings response has out of range index %d", data.Index)
+		}
+		vectors[data.Index] = data.Embedding
+	}
+	for i, vector := range vectors {
+		if vector == nil {
+			return nil, fmt.Errorf("embeddings response is missing index %d", i)
+		}
+	}
+	return vectors, nil
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/pulzeai-oss/knn-router/internal/scorespb"
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/protobuf/proto"
+)
+
+// aggregateFunc computes the weighted average score for each target over the
+// given nearest neighbors.
+type aggregateFunc func(neighbors []Neighbor, weighting Weighting) (*Response, error)
+
+type preloadedPoint struct {
+	category string
+	targets  []int
+	scores   []float32
+}
+
+// pointTable is an immutable in-memory copy of the points bucket, with target
+// names interned to dense ids.
+type pointTable struct {
+	targets []string
+	points  map[string]preloadedPoint
+}
+
+// loadPointTable decodes every point in the points bucket.
+func loadPointTable(b *bolt.Bucket) (*pointTable, error) {
+	t := pointTable{points: make(map[string]preloadedPoint, b.Stats().KeyN)}
+	ids := make(map[string]int)
+	err := b.ForEach(func(k, v []byte) error {
+		var payload scorespb.Point
+		if err := proto.Unmarshal(v, &payload); err != nil {
+			return fmt.Errorf("failed to decode targets for UID %s: %v", k, err)
+		}
+		point := preloadedPoint{
+			category: payload.GetCategory(),
+			targets:  make([]int, len(payload.GetScores())),
+			scores:   make([]float32, len(payload.GetScores())),
+		}
+		for i, score := range payload.GetScores() {
+			id, ok := ids[score.GetTarget()]
+			if !ok {
+				id = len(t.targets)
+				ids[score.GetTarget()] = id
+				t.targets = append(t.targets, score.GetTarget())
+			}
+			point.targets[i] = id
+			point.scores[i] = score.GetScore()
+		}
+		t.points[string(k)] = point
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Aggregate is equivalent to the package-level Aggregate, but accumulates
+// scores in slices indexed by target id.
+func (t *pointTable) Aggregate(neighbors []Neighbor, weighting Weighting) (*Response, error) {
+	res := Response{Weighting: weighting}
+	weights, weightSum := neighborWeights(neighbors, weighting)
+	scoresSum := make([]float32, len(t.targets))
+	found := make([]bool, len(t.targets))
+	for i, neighbor := range neighbors {
+		point, ok := t.points[neighbor.ID]
+		if !ok {
+			return nil, lookupError(neighbor.ID, missingPointError(neighbor.ID))
+		}
+		res.Hits = append(
+			res.Hits,
+			Hit{
+				ID:         neighbor.ID,
+				Category:   point.category,
+				Similarity: neighbor.Similarity,
+				Weight:     weights[i],
+			},
+		)
+		for j, id := range point.targets {
+			scoresSum[id] += point.scores[j] * weights[i]
+			found[id] = true
+		}
+	}
+	for id, score := range scoresSum {
+		if found[id] {
+			res.Scores = append(res.Scores, Score{Target: t.targets[id], Score: score / weightSum})
+		}
+	}
+	SortScores(res.Scores)
+	return &res, nil
+}
+
+// bucketAggregate returns an aggregateFunc that looks up and decodes the
+// target scores of each neighbor in the points bucket.
+func bucketAggregate(b *bolt.Bucket) aggregateFunc {
+	return func(neighbors []Neighbor, weighting Weighting) (*Response, error) {
+		return Aggregate(neighbors, weighting, func(uid string) (*scorespb.Point, error) {
+			// Lookup target scores in DB for given UID
+			v := b.Get([]byte(uid))
+			if v == nil {
+				return nil, missingPointError(uid)
+			}
+			var payload scorespb.Point
+			if err := proto.Unmarshal(v, &payload); err != nil {
+				return nil, err
+			}
+			return &payload, nil
+		})
+	}
+}
+
+// view calls fn with an aggregateFunc over the dataset's preloaded points, or
+// otherwise over its points bucket within a single read transaction.
+func (d *dataset) view(fn func(aggregate aggregateFunc) error) error {
+	if d.points != nil {
+		return fn(d.points.Aggregate)
+	}
+	return d.DB.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(d.collection))
+		if b == nil {
+			return fmt.Errorf("could not find bucket %s", d.collection)
+		}
+		return fn(bucketAggregate(b))
+	})
+}
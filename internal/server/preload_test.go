@@ -0,0 +1,113 @@
+package server
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/pulzeai-oss/knn-router/internal/scorespb"
+	"github.com/pulzeai-oss/knn-router/internal/store"
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestPointTableMatchesBucketAggregate(t *testing.T) {
+	// The points share targets in different orders, so that target ids are
+	// interned in a different order than they are scored
+	points := map[string]*scorespb.Point{
+		"a": {Category: "math", Scores: []*scorespb.Score{{Target: "x", Score: 0.9}, {Target: "y", Score: 0.1}}},
+		"b": {Category: "code", Scores: []*scorespb.Score{{Target: "z", Score: 0.7}, {Target: "x", Score: 0.3}}},
+		"c": {Category: "chat", Scores: []*scorespb.Score{{Target: "y", Score: 0.5}, {Target: "z", Score: 0.2}, {Target: "w", Score: 0.3}}},
+		"d": {Category: "empty"},
+	}
+	DB, err := bolt.Open(filepath.Join(t.TempDir(), "scores.db"), 0600, nil)
+	if err != nil {
+		t.Fatalf("failed to create scores database: %v", err)
+	}
+	defer DB.Close()
+	err = DB.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucket([]byte(store.PointsCollection))
+		if err != nil {
+			return err
+		}
+		for uid, point := range points {
+			v, err := proto.Marshal(point)
+			if err != nil {
+				return err
+			}
+			if err := b.Put([]byte(uid), v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to write scores database: %v", err)
+	}
+
+	for _, tt := range []struct {
+		name      string
+		neighbors []Neighbor
+		weighting Weighting
+		wantErr   bool
+	}{
+		{
+			name:      "all points",
+			neighbors: []Neighbor{{ID: "c", Similarity: 0.9}, {ID: "a", Similarity: 0.8}, {ID: "b", Similarity: 0.4}, {ID: "d", Similarity: 0.1}},
+			weighting: Weighting{Kernel: SimilarityKernel},
+		},
+		{
+			name:      "subset of targets",
+			neighbors: []Neighbor{{ID: "b", Similarity: 0.7}, {ID: "a", Similarity: 0.6}},
+			weighting: Weighting{Kernel: RankKernel, Decay: 0.5},
+		},
+		{
+			name:      "point without scores",
+			neighbors: []Neighbor{{ID: "d", Similarity: 0.5}},
+			weighting: Weighting{Kernel: UniformKernel},
+		},
+		{
+			name:      "zero weights",
+			neighbors: []Neighbor{{ID: "a", Similarity: 0.5}, {ID: "c", Similarity: -0.5}},
+			weighting: Weighting{Kernel: SimilarityKernel},
+		},
+		{
+			name:      "missing point",
+			neighbors: []Neighbor{{ID: "a", Similarity: 0.9}, {ID: "missing", Similarity: 0.8}},
+			weighting: Weighting{Kernel: SimilarityKernel},
+			wantErr:   true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := DB.View(func(tx *bolt.Tx) error {
+				b := tx.Bucket([]byte(store.PointsCollection))
+				table, err := loadPointTable(b)
+				if err != nil {
+					return err
+				}
+				want, wantErr := bucketAggregate(b)(tt.neighbors, tt.weighting)
+				got, err := table.Aggregate(tt.neighbors, tt.weighting)
+				if tt.wantErr {
+					if wantErr == nil || err == nil || err.Error() != wantErr.Error() {
+						t.Errorf("preloaded error = %v, bucket error = %v, want the same error", err, wantErr)
+					}
+					return nil
+				}
+				if wantErr != nil || err != nil {
+					t.Errorf("preloaded error = %v, bucket error = %v", err, wantErr)
+					return nil
+				}
+				if !slices.Equal(got.Hits, want.Hits) {
+					t.Errorf("preloaded hits = %v, bucket hits = %v", got.Hits, want.Hits)
+				}
+				if !equalScores(got.Scores, want.Scores) {
+					t.Errorf("preloaded scores = %v, bucket scores = %v", got.Scores, want.Scores)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("failed to preload points: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// TargetHeader reports the target that a proxied chat completion was routed
+// to.
+const TargetHeader = "X-KNN-Router-Target"
+
+// Upstream is an OpenAI-compatible API serving the model of a target.
+type Upstream struct {
+	BaseURL string
+	// Model replaces the model of proxied requests.
+	Model  string
+	APIKey string
+}
+
+// chatError writes an error in the format of the OpenAI API.
+func chatError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]string{"message": message, "type": "invalid_request_error"},
+	})
+}
+
+// chatCompletionsHandler routes an OpenAI chat completion request on its
+// messages, selected with the server's turn selection, and forwards it to the
+// upstream of the chosen target, with the model rewritten. Streamed responses
+// are passed through as they arrive.
+func (s *Server) chatCompletionsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		chatError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		chatError(w, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+	defer r.Body.Close()
+
+	// Keep the fields of the request that the router does not know about
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		chatError(w, http.StatusBadRequest, "failed to parse request body")
+		return
+	}
+	var messages []ChatMessage
+	if err := json.Unmarshal(fields["messages"], &messages); err != nil {
+		chatError(w, http.StatusBadRequest, "messages are required")
+		return
+	}
+
+	// Only route to targets with an upstream, and that the API key allows
+	payload := Request{
+		Messages:         messages,
+		TruncateStrategy: Middle,
+	}
+	applyKeyDefaults(r.Context(), &payload)
+	allowed := payload.AllowedTargets
+	payload.AllowedTargets = nil
+	for target := range s.upstreams {
+		if len(allowed) == 0 || slices.Contains(allowed, target) {
+			payload.AllowedTargets = append(payload.AllowedTargets, target)
+		}
+	}
+	if len(payload.AllowedTargets) == 0 {
+		chatError(w, http.StatusServiceUnavailable, "no target available for request")
+		return
+	}
+	if err := s.validate(&payload); err != nil {
+		chatError(w, http.StatusBadRequest, fmt.Sprintf("failed to route request: %v", err))
+		return
+	}
+	res, err := s.query(r.Context(), &payload)
+	if errors.Is(err, ErrNoTargets) || (err == nil && res.Decision == nil) {
+		chatError(w, http.StatusServiceUnavailable, "no target available for request")
+		return
+	}
+	if err != nil {
+		chatError(w, http.StatusInternalServerError, "failed to retrieve scores")
+		return
+	}
+	target := res.Decision.Target
+	upstream := s.upstreams[target]
+
+	// Forward the request with the model of the chosen target
+	model, _ := json.Marshal(upstream.Model)
+	fields["model"] = model
+	body, err = json.Marshal(fields)
+	if err != nil {
+		chatError(w, http.StatusInternalServerError, "failed to encode request body")
+		return
+	}
+	upstreamReq, err := http.NewRequestWithContext(
+		r.Context(),
+		http.MethodPost,
+		strings.TrimSuffix(upstream.BaseURL, "/")+"/chat/completions",
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		chatError(w, http.StatusInternalServerError, "failed to create upstream request")
+		return
+	}
+	upstreamReq.Header.Set("Content-Type", "application/json")
+	if accept := r.Header.Get("Accept"); accept != "" {
+		upstreamReq.Header.Set("Accept", accept)
+	}
+	if upstream.APIKey != "" {
+		upstreamReq.Header.Set("Authorization", "Bearer "+upstream.APIKey)
+	}
+	upstreamResp, err := http.DefaultClient.Do(upstreamReq)
+	if err != nil {
+		log.Printf("failed to call upstream for target %s: %v", target, err)
+		chatError(w, http.StatusBadGateway, fmt.Sprintf("failed to call upstream for target %s", target))
+		return
+	}
+	defer upstreamResp.Body.Close()
+
+	for _, header := range []string{"Content-Type", "Cache-Control"} {
+		if v := upstreamResp.Header.Get(header); v != "" {
+			w.Header().Set(header, v)
+		}
+	}
+	w.Header().Set(TargetHeader, target)
+	w.WriteHeader(upstreamResp.StatusCode)
+
+	// Flush as each chunk arrives, so streamed responses are not buffered
+	rc := http.NewResponseController(w)
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := upstreamResp.Body.Read(buf)
+		if n > 0 {
+			if _, err := w.Write(buf[:n]); err != nil {
+				return
+			}
+			rc.Flush()
+		}
+		if err != nil {
+			if err != io.EOF {
+				log.Printf("failed to read upstream response for target %s: %v", target, err)
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	qdrant "github.com/qdrant/go-client/qdrant"
+	"google.golang.org/grpc"
+)
+
+const scrollPageSize = 1000
+
+// QdrantIndex is a VectorIndex backed by a Qdrant collection.
+type QdrantIndex struct {
+	pointsClient qdrant.PointsClient
+	collection   string
+}
+
+func NewQdrantIndex(conn *grpc.ClientConn, collection string) *QdrantIndex {
+	return &QdrantIndex{
+		pointsClient: qdrant.NewPointsClient(conn),
+		collection:   collection,
+	}
+}
+
+func (q *QdrantIndex) searchParams(vector []float32, limit int, filter *Filter) *qdrant.SearchPoints {
+	return &qdrant.SearchPoints{
+		CollectionName: q.collection,
+		Vector:         vector,
+		Limit:          uint64(limit),
+		Filter:         qdrantFilter(filter),
+		WithVectors: &qdrant.WithVectorsSelector{
+			SelectorOptions: &qdrant.WithVectorsSelector_Enable{Enable: false},
+		},
+		WithPayload: &qdrant.WithPayloadSelector{
+			SelectorOptions: &qdrant.WithPayloadSelector_Enable{Enable: false},
+		},
+	}
+}
+
+func (q *QdrantIndex) Search(
+	ctx context.Context,
+	vector []float32,
+	limit int,
+	filter *Filter,
+) ([]Neighbor, error) {
+	search, err := q.pointsClient.Search(ctx, q.searchParams(vector, limit, filter))
+	if err != nil {
+		return nil, err
+	}
+	return fromScoredPoints(search.GetResult()), nil
+}
+
+func (q *QdrantIndex) SearchBatch(
+	ctx context.Context,
+	vectors [][]float32,
+	limit int,
+	filters []*Filter,
+) ([][]Neighbor, error) {
+	searches := make([]*qdrant.SearchPoints, len(vectors))
+	for i, vector := range vectors {
+		searches[i] = q.searchParams(vector, limit, filters[i])
+	}
+	searchResp, err := q.pointsClient.SearchBatch(ctx, &qdrant.SearchBatchPoints{
+		CollectionName: q.collection,
+		SearchPoints:   searches,
+	})
+	if err != nil {
+		return nil, err
+	}
+	neighbors := make([][]Neighbor, len(searchResp.GetResult()))
+	for i, batch := range searchResp.GetResult() {
+		neighbors[i] = fromScoredPoints(batch.GetResult())
+	}
+	return neighbors, nil
+}
+
+func (q *QdrantIndex) Count(ctx context.Context) (uint64, error) {
+	exact := true
+	countResp, err := q.pointsClient.Count(ctx, &qdrant.CountPoints{
+		CollectionName: q.collection,
+		Exact:          &exact,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count points in collection %s: %v", q.collection, err)
+	}
+	return countResp.GetResult().GetCount(), nil
+}
+
+func (q *QdrantIndex) UIDs(ctx context.Context) ([]string, error) {
+	limit := uint32(scrollPageSize)
+	var uids []string
+	var offset *qdrant.PointId
+	for {
+		scrollResp, err := q.pointsClient.Scroll(ctx, &qdrant.ScrollPoints{
+			CollectionName: q.collection,
+			Offset:         offset,
+			Limit:          &limit,
+			WithVectors: &qdrant.WithVectorsSelector{
+				SelectorOptions: &qdrant.WithVectorsSelector_Enable{Enable: false},
+			},
+			WithPayload: &qdrant.WithPayloadSelector{
+				SelectorOptions: &qdrant.WithPayloadSelector_Enable{Enable: false},
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scroll points in collection %s: %v", q.collection, err)
+		}
+		for _, pt := range scrollResp.GetResult() {
+			uids = append(uids, pt.GetId().GetUuid())
+		}
+		offset = scrollResp.GetNextPageOffset()
+		if offset == nil {
+			return uids, nil
+		}
+	}
+}
+
+func fromScoredPoints(points []*qdrant.ScoredPoint) []Neighbor {
+	neighbors := make([]Neighbor, len(points))
+	for i, pt := range points {
+		neighbors[i] = Neighbor{ID: pt.GetId().GetUuid(), Similarity: pt.GetScore()}
+	}
+	return neighbors
+}
+
+// qdrantFilter converts a validated filter into a Qdrant filter.
+func qdrantFilter(filter *Filter) *qdrant.Filter {
+	if filter == nil {
+		return nil
+	}
+	return &qdrant.Filter{
+		Must:    qdrantConditions(filter.Must),
+		Should:  qdrantConditions(filter.Should),
+		MustNot: qdrantConditions(filter.MustNot),
+	}
+}
+
+func qdrantConditions(conditions []Condition) []*qdrant.Condition {
+	converted := make([]*qdrant.Condition, len(conditions))
+	for i, c := range conditions {
+		field := &qdrant.FieldCondition{Key: c.Key}
+		if c.Match != nil {
+			field.Match = qdrantMatch(c.Match)
+		}
+		if c.Range != nil {
+			field.Range = &qdrant.Range{Gt: c.Range.Gt, Gte: c.Range.Gte, Lt: c.Range.Lt, Lte: c.Range.Lte}
+		}
+		converted[i] = &qdrant.Condition{
+			ConditionOneOf: &qdrant.Condition_Field{Field: field},
+		}
+	}
+	return converted
+}
+
+func qdrantMatch(m *Match) *qdrant.Match {
+	kind, _ := m.kind()
+	switch kind {
+	case matchKeyword:
+		return &qdrant.Match{MatchValue: &qdrant.Match_Keyword{Keyword: m.Value.(string)}}
+	case matchInteger:
+		v, _ := integer(m.Value)
+		return &qdrant.Match{MatchValue: &qdrant.Match_Integer{Integer: v}}
+	case matchBoolean:
+		return &qdrant.Match{MatchValue: &qdrant.Match_Boolean{Boolean: m.Value.(bool)}}
+	case matchKeywords:
+		keywords := make([]string, len(m.Any))
+		for i, v := range m.Any {
+			keywords[i] = v.(string)
+		}
+		return &qdrant.Match{MatchValue: &qdrant.Match_Keywords{
+			Keywords: &qdrant.RepeatedStrings{Strings: keywords},
+		}}
+	}
+	integers := make([]int64, len(m.Any))
+	for i, v := range m.Any {
+		integers[i], _ = integer(v)
+	}
+	return &qdrant.Match{MatchValue: &qdrant.Match_Integers{
+		Integers: &qdrant.RepeatedIntegers{Integers: integers},
+	}}
+}
@@ -0,0 +1,242 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pulzeai-oss/knn-router/internal/store"
+	bolt "go.etcd.io/bbolt"
+)
+
+// IndexFactory returns the vector index to serve alongside a scores database,
+// over the given vector collection.
+type IndexFactory func(DB *bolt.DB, collection string) (VectorIndex, error)
+
+// dataset is a scores database and its vector index, which are swapped
+// together on reload. It is closed once the requests using it are done.
+type dataset struct {
+	DB *bolt.DB
+	// collection is the name of the points bucket.
+	collection string
+	// vectorCollection is the vector index collection of the points.
+	vectorCollection string
+	index            VectorIndex
+	// points is the preloaded points bucket, if enabled.
+	points *pointTable
+	refs   sync.WaitGroup
+}
+
+func (s *Server) openDataset() (*dataset, error) {
+	DB, err := bolt.Open(s.dbPath, 0600, &bolt.Options{ReadOnly: true, Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open scores database: %v", err)
+	}
+	var points *pointTable
+	// Databases written before the collection was recorded use the collection
+	// named after the points bucket
+	vectorCollection := s.collection
+	err = DB.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(s.collection))
+		if b == nil {
+			return fmt.Errorf("could not find bucket %s", s.collection)
+		}
+		if collections := tx.Bucket([]byte(store.CollectionsBucket)); collections != nil {
+			if v := collections.Get([]byte(s.collection)); v != nil {
+				vectorCollection = string(v)
+			}
+		}
+		if s.preload {
+			points, err = loadPointTable(b)
+		}
+		return err
+	})
+	if err != nil {
+		DB.Close()
+		return nil, err
+	}
+	index, err := s.newIndex(DB, vectorCollection)
+	if err != nil {
+		DB.Close()
+		return nil, fmt.Errorf("failed to load vector index: %v", err)
+	}
+	return &dataset{
+		DB:               DB,
+		collection:       s.collection,
+		vectorCollection: vectorCollection,
+		index:            index,
+		points:           points,
+	}, nil
+}
+
+// validate checks that the points in the vector index are exactly those in
+// the scores database.
+func (d *dataset) validate(ctx context.Context) error {
+	uids := make(map[string]bool)
+	err := d.DB.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(d.collection)).ForEach(func(k, _ []byte) error {
+			uids[string(k)] = false
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+	indexUIDs, err := d.index.UIDs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list points in vector index: %v", err)
+	}
+	var missing int
+	for _, uid := range indexUIDs {
+		if _, exists := uids[uid]; !exists {
+			missing++
+			continue
+		}
+		uids[uid] = true
+	}
+	if missing > 0 {
+		return fmt.Errorf("%d points in the vector index are missing from the scores database", missing)
+	}
+	for _, found := range uids {
+		if !found {
+			missing++
+		}
+	}
+	if missing > 0 {
+		return fmt.Errorf("%d points in the scores database are missing from the vector index", missing)
+	}
+	return nil
+}
+
+// acquire returns the current dataset, which must be released once the
+// caller is done with it.
+func (s *Server) acquire() (*dataset, func()) {
+	s.dataMu.RLock()
+	defer s.dataMu.RUnlock()
+	d := s.data
+	d.refs.Add(1)
+	return d, d.refs.Done
+}
+
+// swap replaces the current dataset, and closes the previous one once the
+// requests using it are done.
+func (s *Server) swap(d *dataset) {
+	s.dataMu.Lock()
+	prev := s.data
+	s.data = d
+	s.dataMu.Unlock()
+	if prev == nil {
+		return
+	}
+	go func() {
+		prev.refs.Wait()
+		if err := prev.DB.Close(); err != nil {
+			log.Printf("failed to close previous scores database: %v", err)
+		}
+	}()
+}
+
+// Reload opens the scores database at the configured path, checks it against
+// its vector index, and swaps it in without interrupting in-flight requests.
+// The current dataset is kept if any check fails.
+func (s *Server) Reload(ctx context.Context) error {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	d, err := s.openDataset()
+	if err == nil {
+		err = d.validate(ctx)
+		if err != nil {
+			d.DB.Close()
+		}
+	}
+	if err != nil {
+		reloadsTotal.WithLabelValues("error").Inc()
+		return err
+	}
+	s.swap(d)
+	reloadsTotal.WithLabelValues("ok").Inc()
+	log.Printf("reloaded scores database from %s, with vector collection %s", s.dbPath, d.vectorCollection)
+	return nil
+}
+
+// WatchDB polls the scores database file, and reloads it when it is
+// replaced. Updates should replace the file atomically, e.g. by renaming a
+// new file over it, rather than writing to it in place.
+func (s *Server) WatchDB(ctx context.Context, interval time.Duration) {
+	watchFile(ctx, s.dbPath, interval, func() {
+		if err := s.Reload(ctx); err != nil {
+			log.Printf("failed to reload scores database: %v", err)
+		}
+	})
+}
+
+// watchFile polls the file at path until ctx is done, and calls fn when it is
+// replaced or modified.
+func watchFile(ctx context.Context, path string, interval time.Duration, fn func()) {
+	prev, _ := os.Stat(path)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if prev != nil && os.SameFile(prev, info) && info.ModTime().Equal(prev.ModTime()) &&
+			info.Size() == prev.Size() {
+			continue
+		}
+		prev = info
+		fn()
+	}
+}
+
+func (s *Server) reloadHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	router, ok := s.router(r.URL.Query().Get("router"))
+	if !ok {
+		http.Error(w, "unknown router: "+r.URL.Query().Get("router"), http.StatusNotFound)
+		return
+	}
+	res := CheckResult{Status: "ok"}
+	w.Header().Set("Content-Type", "application/json")
+	if err := router.Reload(r.Context()); err != nil {
+		res = CheckResult{Status: "error", Error: err.Error()}
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// Close closes the current dataset once the requests using it are done. It
+// gives up and leaves the dataset open if ctx is done first.
+func (s *Server) Close(ctx context.Context) error {
+	s.dataMu.RLock()
+	d := s.data
+	s.dataMu.RUnlock()
+	released := make(chan struct{})
+	go func() {
+		d.refs.Wait()
+		close(released)
+	}()
+	select {
+	case <-released:
+	case <-ctx.Done():
+		return fmt.Errorf("scores database is still in use: %v", ctx.Err())
+	}
+	return d.DB.Close()
+}
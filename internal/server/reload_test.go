@@ -0,0 +1,291 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pulzeai-oss/knn-router/internal/scorespb"
+	"github.com/pulzeai-oss/knn-router/internal/store"
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/protobuf/proto"
+)
+
+// staticIndex is a VectorIndex over a fixed set of points.
+type staticIndex struct {
+	collection string
+	uids       []string
+}
+
+func (i *staticIndex) Search(context.Context, []float32, int, *Filter) ([]Neighbor, error) {
+	neighbors := make([]Neighbor, len(i.uids))
+	for j, uid := range i.uids {
+		neighbors[j] = Neighbor{ID: uid, Similarity: 1}
+	}
+	return neighbors, nil
+}
+
+func (i *staticIndex) SearchBatch(ctx context.Context, vectors [][]float32, limit int, _ []*Filter) ([][]Neighbor, error) {
+	results := make([][]Neighbor, len(vectors))
+	for j := range vectors {
+		results[j], _ = i.Search(ctx, nil, limit, nil)
+	}
+	return results, nil
+}
+
+func (i *staticIndex) Count(context.Context) (uint64, error) {
+	return uint64(len(i.uids)), nil
+}
+
+func (i *staticIndex) UIDs(context.Context) ([]string, error) {
+	return i.uids, nil
+}
+
+// writeScoresDB writes a scores database with the given points, scored for a
+// single target, and records their vector collection if it is not empty.
+func writeScoresDB(t *testing.T, path, collection, vectorCollection string, uids []string) {
+	t.Helper()
+	DB, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatalf("failed to create scores database: %v", err)
+	}
+	defer DB.Close()
+	err = DB.Update(func(tx *bolt.Tx) error {
+		if vectorCollection != "" {
+			b, err := tx.CreateBucketIfNotExists([]byte(store.CollectionsBucket))
+			if err != nil {
+				return err
+			}
+			if err := b.Put([]byte(collection), []byte(vectorCollection)); err != nil {
+				return err
+			}
+		}
+		b, err := tx.CreateBucketIfNotExists([]byte(collection))
+		if err != nil {
+			return err
+		}
+		for _, uid := range uids {
+			v, err := proto.Marshal(&scorespb.Point{
+				Category: "test",
+				Scores:   []*scorespb.Score{{Target: "target", Score: 1}},
+			})
+			if err != nil {
+				return err
+			}
+			if err := b.Put([]byte(uid), v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to write scores database: %v", err)
+	}
+}
+
+// testIndexes returns an IndexFactory that serves the given points of each
+// vector collection.
+func testIndexes(collections map[string][]string) IndexFactory {
+	var mu sync.Mutex
+	return func(_ *bolt.DB, collection string) (VectorIndex, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return &staticIndex{collection: collection, uids: collections[collection]}, nil
+	}
+}
+
+func newTestServer(t *testing.T, path string, newIndex IndexFactory) *Server {
+	t.Helper()
+	s := &Server{newIndex: newIndex, dbPath: path, collection: store.PointsCollection}
+	d, err := s.openDataset()
+	if err != nil {
+		t.Fatalf("failed to open dataset: %v", err)
+	}
+	s.swap(d)
+	return s
+}
+
+// replaceDB atomically replaces the scores database at path.
+func replaceDB(t *testing.T, path, vectorCollection string, uids []string) {
+	t.Helper()
+	tmp := path + ".new"
+	writeScoresDB(t, tmp, store.PointsCollection, vectorCollection, uids)
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatalf("failed to replace scores database: %v", err)
+	}
+}
+
+// closed reports whether a bolt database has been closed.
+func closed(DB *bolt.DB) bool {
+	return DB.View(func(*bolt.Tx) error { return nil }) == bolt.ErrDatabaseNotOpen
+}
+
+func TestReloadSwapsVectorCollection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scores.db")
+	writeScoresDB(t, path, store.PointsCollection, "", []string{"a", "b"})
+	s := newTestServer(t, path, testIndexes(map[string][]string{
+		store.PointsCollection: {"a", "b"},
+		"main-v2":              {"b", "c"},
+	}))
+	defer s.Close(context.Background())
+
+	// Databases without a recorded collection use the points bucket's name
+	d, release := s.acquire()
+	if got := d.index.(*staticIndex).collection; got != store.PointsCollection {
+		t.Errorf("vector collection = %s, want %s", got, store.PointsCollection)
+	}
+	release()
+
+	replaceDB(t, path, "main-v2", []string{"b", "c"})
+	if err := s.Reload(context.Background()); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	d, release = s.acquire()
+	defer release()
+	if got := d.index.(*staticIndex).collection; got != "main-v2" {
+		t.Errorf("vector collection after reload = %s, want main-v2", got)
+	}
+}
+
+func TestReloadKeepsDatasetOnMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scores.db")
+	writeScoresDB(t, path, store.PointsCollection, "", []string{"a", "b"})
+	s := newTestServer(t, path, testIndexes(map[string][]string{store.PointsCollection: {"a", "b"}}))
+	defer s.Close(context.Background())
+	prev, release := s.acquire()
+	release()
+
+	// The new points are not in the vector index
+	replaceDB(t, path, "", []string{"a", "b", "c"})
+	if err := s.Reload(context.Background()); err == nil {
+		t.Fatal("Reload succeeded with points missing from the vector index")
+	}
+	d, release := s.acquire()
+	defer release()
+	if d != prev {
+		t.Error("Reload swapped in a dataset that failed validation")
+	}
+	if closed(d.DB) {
+		t.Error("Reload closed the current dataset")
+	}
+}
+
+func TestSwapClosesPreviousDatasetWhenReleased(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scores.db")
+	writeScoresDB(t, path, store.PointsCollection, "", []string{"a"})
+	s := newTestServer(t, path, testIndexes(map[string][]string{store.PointsCollection: {"a"}}))
+	defer s.Close(context.Background())
+
+	// Hold the dataset like an in-flight request
+	prev, release := s.acquire()
+	if err := s.Reload(context.Background()); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	d, releaseNext := s.acquire()
+	releaseNext()
+	if d == prev {
+		t.Fatal("Reload did not swap in a new dataset")
+	}
+
+	// The previous dataset stays open until it is released
+	time.Sleep(10 * time.Millisecond)
+	if closed(prev.DB) {
+		t.Fatal("previous dataset was closed while in use")
+	}
+	err := prev.view(func(aggregate aggregateFunc) error {
+		_, err := aggregate([]Neighbor{{ID: "a", Similarity: 1}}, UniformWeighting)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("query against the previous dataset failed: %v", err)
+	}
+	release()
+	deadline := time.Now().Add(time.Second)
+	for !closed(prev.DB) {
+		if time.Now().After(deadline) {
+			t.Fatal("previous dataset was not closed after it was released")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestConcurrentQueriesDuringReloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scores.db")
+	writeScoresDB(t, path, store.PointsCollection, "", []string{"a", "b"})
+	s := newTestServer(t, path, testIndexes(map[string][]string{store.PointsCollection: {"a", "b"}}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				d, release := s.acquire()
+				neighbors, _ := d.index.Search(ctx, nil, 2, nil)
+				err := d.view(func(aggregate aggregateFunc) error {
+					_, err := aggregate(neighbors, UniformWeighting)
+					return err
+				})
+				release()
+				if err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		if err := s.Reload(context.Background()); err != nil {
+			t.Errorf("Reload failed: %v", err)
+		}
+	}
+	cancel()
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("query during reload failed: %v", err)
+	}
+
+	d, release := s.acquire()
+	release()
+	if err := s.Close(context.Background()); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if !closed(d.DB) {
+		t.Error("Close did not close the current dataset")
+	}
+}
+
+func TestCloseGivesUpAtDeadline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scores.db")
+	writeScoresDB(t, path, store.PointsCollection, "", []string{"a"})
+	s := newTestServer(t, path, testIndexes(map[string][]string{store.PointsCollection: {"a"}}))
+
+	// A request that outlives the deadline leaves the dataset open
+	d, release := s.acquire()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Close(ctx); err == nil {
+		t.Fatal("Close succeeded with a dataset in use")
+	}
+	if closed(d.DB) {
+		t.Fatal("Close closed a dataset in use")
+	}
+
+	// New requests are not blocked by a pending Close
+	_, releaseNext := s.acquire()
+	releaseNext()
+
+	release()
+	if err := s.Close(context.Background()); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if !closed(d.DB) {
+		t.Error("Close did not close the released dataset")
+	}
+}
@@ -0,0 +1,345 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Timeouts bounds the calls of each stage of a query to its upstream. Zero
+// means no timeout.
+type Timeouts struct {
+	Tokenize time.Duration
+	Embed    time.Duration
+	Search   time.Duration
+}
+
+// stageContext returns a context for the calls of the given stage.
+func (t Timeouts) stageContext(ctx context.Context, stage string) (context.Context, context.CancelFunc) {
+	var timeout time.Duration
+	switch stage {
+	case stageTokenize:
+		timeout = t.Tokenize
+	case stageEmbed:
+		timeout = t.Embed
+	case stageSearch:
+		timeout = t.Search
+	}
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// UpstreamPolicy configures the retries and circuit breaker of the calls to
+// an upstream gRPC service.
+type UpstreamPolicy struct {
+	// MaxRetries is the number of times a call that failed with a retryable
+	// code is retried. Streams are only retried until their first response.
+	MaxRetries int
+	// RetryBackoff is the base of the exponential backoff between retries,
+	// with full jitter.
+	RetryBackoff time.Duration
+	// BreakerFailures is the number of consecutive failures that open the
+	// circuit breaker, or 0 to disable it.
+	BreakerFailures int
+	// BreakerCooldown is how long the circuit breaker fails calls fast before
+	// letting a trial call through.
+	BreakerCooldown time.Duration
+}
+
+// retryable reports whether a failed call may succeed if retried.
+func retryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted, codes.Aborted:
+		return true
+	}
+	return false
+}
+
+// upstreamFailure reports whether an error indicates that the upstream is
+// unhealthy, as opposed to a bad request or a cancelled caller.
+func upstreamFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal, codes.Unknown:
+		return true
+	}
+	return false
+}
+
+const (
+	breakerClosed = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// circuitBreaker fails calls fast after consecutive upstream failures, and
+// lets a single trial call through once the cooldown has elapsed.
+type circuitBreaker struct {
+	upstream  string
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    int
+	failures int
+	openedAt time.Time
+}
+
+func (b *circuitBreaker) setState(state int) {
+	b.state = state
+	breakerState.WithLabelValues(b.upstream).Set(float64(state))
+}
+
+func (b *circuitBreaker) allow() error {
+	if b.threshold <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return status.Errorf(codes.Unavailable, "circuit breaker for upstream %s is open", b.upstream)
+		}
+		b.setState(breakerHalfOpen)
+		return nil
+	case breakerHalfOpen:
+		return status.Errorf(codes.Unavailable, "circuit breaker for upstream %s is open", b.upstream)
+	}
+	return nil
+}
+
+func (b *circuitBreaker) record(err error) {
+	if b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !upstreamFailure(err) {
+		b.failures = 0
+		if b.state != breakerClosed {
+			b.setState(breakerClosed)
+		}
+		return
+	}
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.openedAt = time.Now()
+		b.setState(breakerOpen)
+	}
+}
+
+// backoff returns a random delay of up to base*2^attempt.
+func backoff(base time.Duration, attempt int) time.Duration {
+	return time.Duration(rand.Int63n(int64(base<<attempt) + 1))
+}
+
+// resilienceDialOptions returns dial options that retry failed calls, and
+// fail calls fast while the circuit breaker of the upstream is open.
+func resilienceDialOptions(upstream string, policy UpstreamPolicy) []grpc.DialOption {
+	breaker := &circuitBreaker{
+		upstream:  upstream,
+		threshold: policy.BreakerFailures,
+		cooldown:  policy.BreakerCooldown,
+	}
+	breakerState.WithLabelValues(upstream).Set(breakerClosed)
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(func(
+			ctx context.Context,
+			method string,
+			req, reply any,
+			cc *grpc.ClientConn,
+			invoker grpc.UnaryInvoker,
+			opts ...grpc.CallOption,
+		) error {
+			for attempt := 0; ; attempt++ {
+				if err := breaker.allow(); err != nil {
+					return err
+				}
+				err := invoker(ctx, method, req, reply, cc, opts...)
+				breaker.record(err)
+				if err == nil || attempt >= policy.MaxRetries || !retryable(err) {
+					return err
+				}
+				upstreamRetriesTotal.WithLabelValues(upstream, method).Inc()
+				select {
+				case <-time.After(backoff(policy.RetryBackoff, attempt)):
+				case <-ctx.Done():
+					return err
+				}
+			}
+		}),
+		grpc.WithChainStreamInterceptor(func(
+			ctx context.Context,
+			desc *grpc.StreamDesc,
+			cc *grpc.ClientConn,
+			method string,
+			streamer grpc.Streamer,
+			opts ...grpc.CallOption,
+		) (grpc.ClientStream, error) {
+			newStream := func() (grpc.ClientStream, error) {
+				if err := breaker.allow(); err != nil {
+					return nil, err
+				}
+				stream, err := streamer(ctx, desc, cc, method, opts...)
+				if err != nil {
+					breaker.record(err)
+					return nil, err
+				}
+				return &breakerStream{ClientStream: stream, breaker: breaker}, nil
+			}
+			stream, err := newStream()
+			if err != nil {
+				return nil, err
+			}
+			return &retryStream{
+				ctx:       ctx,
+				method:    method,
+				upstream:  upstream,
+				policy:    policy,
+				newStream: newStream,
+				stream:    stream,
+			}, nil
+		}),
+	}
+}
+
+// retryStream retries a stream that failed with a retryable code before its
+// first response, by replaying the messages sent so far on a new stream.
+type retryStream struct {
+	ctx       context.Context
+	method    string
+	upstream  string
+	policy    UpstreamPolicy
+	newStream func() (grpc.ClientStream, error)
+
+	// mu serializes sends with replacing the stream, so that the replayed
+	// messages are sent in order.
+	mu       sync.Mutex
+	stream   grpc.ClientStream
+	sent     []any
+	closed   bool
+	received bool
+	attempt  int
+}
+
+func (s *retryStream) current() grpc.ClientStream {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stream
+}
+
+func (s *retryStream) Header() (metadata.MD, error) {
+	return s.current().Header()
+}
+
+func (s *retryStream) Trailer() metadata.MD {
+	return s.current().Trailer()
+}
+
+func (s *retryStream) Context() context.Context {
+	return s.current().Context()
+}
+
+func (s *retryStream) SendMsg(m any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.received {
+		return s.stream.SendMsg(m)
+	}
+	s.sent = append(s.sent, m)
+	err := s.stream.SendMsg(m)
+	// A broken stream returns io.EOF, and its status from RecvMsg, which
+	// replays the message if the stream is retried
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	return err
+}
+
+func (s *retryStream) CloseSend() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return s.stream.CloseSend()
+}
+
+func (s *retryStream) RecvMsg(m any) error {
+	for {
+		err := s.current().RecvMsg(m)
+		s.mu.Lock()
+		if err == nil && !s.received {
+			s.received = true
+			s.sent = nil
+		}
+		if err == nil || errors.Is(err, io.EOF) || s.received ||
+			s.attempt >= s.policy.MaxRetries || !retryable(err) {
+			s.mu.Unlock()
+			return err
+		}
+		attempt := s.attempt
+		s.attempt++
+		s.mu.Unlock()
+
+		upstreamRetriesTotal.WithLabelValues(s.upstream, s.method).Inc()
+		select {
+		case <-time.After(backoff(s.policy.RetryBackoff, attempt)):
+		case <-s.ctx.Done():
+			return err
+		}
+		if err := s.retry(); err != nil {
+			return err
+		}
+	}
+}
+
+// retry replaces the stream with a new one, and replays the messages sent on
+// the previous one.
+func (s *retryStream) retry() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stream, err := s.newStream()
+	if err != nil {
+		return err
+	}
+	s.stream = stream
+	for _, m := range s.sent {
+		// If the new stream breaks too, RecvMsg returns its status
+		if err := stream.SendMsg(m); err != nil {
+			return nil
+		}
+	}
+	if s.closed {
+		return stream.CloseSend()
+	}
+	return nil
+}
+
+// breakerStream records the outcome of the first receive of a stream with the
+// circuit breaker.
+type breakerStream struct {
+	grpc.ClientStream
+	breaker *circuitBreaker
+	once    sync.Once
+}
+
+func (s *breakerStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	s.once.Do(func() {
+		if errors.Is(err, io.EOF) {
+			s.breaker.record(nil)
+			return
+		}
+		s.breaker.record(err)
+	})
+	return err
+}
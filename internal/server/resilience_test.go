@@ -0,0 +1,248 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pulzeai-oss/knn-router/internal/teipb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+var errUpstream = status.Error(codes.Unavailable, "upstream unavailable")
+
+func newTestBreaker(threshold int) *circuitBreaker {
+	return &circuitBreaker{upstream: "test", threshold: threshold, cooldown: time.Hour}
+}
+
+// expireCooldown makes the cooldown of an open breaker elapse.
+func expireCooldown(b *circuitBreaker) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.openedAt = time.Now().Add(-b.cooldown)
+}
+
+func TestCircuitBreakerOpensAfterConsecutiveFailures(t *testing.T) {
+	b := newTestBreaker(3)
+	for i := 0; i < 2; i++ {
+		b.record(errUpstream)
+	}
+	// A success resets the consecutive failures
+	b.record(nil)
+	for i := 0; i < 2; i++ {
+		b.record(errUpstream)
+	}
+	if err := b.allow(); err != nil {
+		t.Fatalf("breaker opened after 2 consecutive failures: %v", err)
+	}
+	b.record(errUpstream)
+	if err := b.allow(); status.Code(err) != codes.Unavailable {
+		t.Fatalf("allow() = %v, want an Unavailable error from the open breaker", err)
+	}
+}
+
+func TestCircuitBreakerIgnoresCallerErrors(t *testing.T) {
+	b := newTestBreaker(1)
+	for _, err := range []error{
+		status.Error(codes.InvalidArgument, "bad request"),
+		status.Error(codes.Canceled, "cancelled"),
+		status.Error(codes.NotFound, "not found"),
+	} {
+		b.record(err)
+		if err := b.allow(); err != nil {
+			t.Fatalf("breaker opened after a caller error: %v", err)
+		}
+	}
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	b := newTestBreaker(0)
+	for i := 0; i < 10; i++ {
+		b.record(errUpstream)
+	}
+	if err := b.allow(); err != nil {
+		t.Fatalf("disabled breaker failed a call: %v", err)
+	}
+}
+
+func TestCircuitBreakerHalfOpenTrial(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		trial     error
+		wantState int
+	}{
+		{name: "success closes", trial: nil, wantState: breakerClosed},
+		{name: "failure reopens", trial: errUpstream, wantState: breakerOpen},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBreaker(1)
+			b.record(errUpstream)
+			if err := b.allow(); err == nil {
+				t.Fatal("open breaker allowed a call before the cooldown")
+			}
+			expireCooldown(b)
+
+			// Exactly one of the concurrent calls is let through as the trial
+			var allowed atomic.Int32
+			var wg sync.WaitGroup
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if b.allow() == nil {
+						allowed.Add(1)
+					}
+				}()
+			}
+			wg.Wait()
+			if n := allowed.Load(); n != 1 {
+				t.Fatalf("half-open breaker allowed %d calls, want 1", n)
+			}
+
+			b.record(tt.trial)
+			b.mu.Lock()
+			state := b.state
+			b.mu.Unlock()
+			if state != tt.wantState {
+				t.Fatalf("breaker state after trial = %d, want %d", state, tt.wantState)
+			}
+			err := b.allow()
+			if tt.wantState == breakerClosed && err != nil {
+				t.Errorf("closed breaker failed a call: %v", err)
+			}
+			if tt.wantState == breakerOpen && err == nil {
+				t.Error("reopened breaker allowed a call before the cooldown")
+			}
+		})
+	}
+}
+
+// flakyEmbedServer fails the first streams it serves with Unavailable, after
+// receiving their first request and sending failAfter responses.
+type flakyEmbedServer struct {
+	teipb.UnimplementedEmbedServer
+	failures  atomic.Int32
+	failAfter int
+	streams   atomic.Int32
+}
+
+func (s *flakyEmbedServer) EmbedStream(stream teipb.Embed_EmbedStreamServer) error {
+	s.streams.Add(1)
+	for sent := 0; ; sent++ {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if sent == s.failAfter && s.failures.Add(-1) >= 0 {
+			return errUpstream
+		}
+		err = stream.Send(&teipb.EmbedResponse{Embeddings: []float32{float32(len(req.GetInputs()))}})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// newTestEmbedder serves srv in process, and returns a TEIEmbedder that
+// calls it with the given upstream policy.
+func newTestEmbedder(t *testing.T, srv teipb.EmbedServer, policy UpstreamPolicy) *TEIEmbedder {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	teipb.RegisterEmbedServer(s, srv)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.DialContext(
+		context.Background(),
+		"bufnet",
+		append(
+			resilienceDialOptions("test-tei", policy),
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+				return lis.DialContext(ctx)
+			}),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		)...,
+	)
+	if err != nil {
+		t.Fatalf("failed to dial test server: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &TEIEmbedder{embedClient: teipb.NewEmbedClient(conn)}
+}
+
+func TestStreamRetriedBeforeFirstResponse(t *testing.T) {
+	srv := &flakyEmbedServer{}
+	srv.failures.Store(2)
+	embedder := newTestEmbedder(t, srv, UpstreamPolicy{MaxRetries: 2, RetryBackoff: time.Millisecond})
+
+	inputs := []string{"a", "bb", "ccc", "dddd"}
+	vectors, err := embedder.EmbedBatch(context.Background(), inputs)
+	if err != nil {
+		t.Fatalf("EmbedBatch failed: %v", err)
+	}
+	for i, input := range inputs {
+		if len(vectors[i]) != 1 || vectors[i][0] != float32(len(input)) {
+			t.Errorf("embedding of %q = %v, want [%d]", input, vectors[i], len(input))
+		}
+	}
+	if n := srv.streams.Load(); n != 3 {
+		t.Errorf("server served %d streams, want 3", n)
+	}
+}
+
+func TestStreamRetriesExhausted(t *testing.T) {
+	srv := &flakyEmbedServer{}
+	srv.failures.Store(3)
+	embedder := newTestEmbedder(t, srv, UpstreamPolicy{MaxRetries: 2, RetryBackoff: time.Millisecond})
+
+	_, err := embedder.EmbedBatch(context.Background(), []string{"a", "b"})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("EmbedBatch failed with %v, want Unavailable", err)
+	}
+	if n := srv.streams.Load(); n != 3 {
+		t.Errorf("server served %d streams, want 3", n)
+	}
+}
+
+func TestStreamNotRetriedAfterFirstResponse(t *testing.T) {
+	srv := &flakyEmbedServer{failAfter: 1}
+	srv.failures.Store(1)
+	embedder := newTestEmbedder(t, srv, UpstreamPolicy{MaxRetries: 2, RetryBackoff: time.Millisecond})
+
+	_, err := embedder.EmbedBatch(context.Background(), []string{"a", "b", "c"})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("EmbedBatch failed with %v, want Unavailable", err)
+	}
+	if n := srv.streams.Load(); n != 1 {
+		t.Errorf("server served %d streams, want 1", n)
+	}
+}
+
+func TestStreamRetryStopsAtDeadline(t *testing.T) {
+	srv := &flakyEmbedServer{}
+	srv.failures.Store(100)
+	embedder := newTestEmbedder(t, srv, UpstreamPolicy{MaxRetries: 100, RetryBackoff: 10 * time.Millisecond})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := embedder.EmbedBatch(ctx, []string{"a"}); err == nil {
+		t.Fatal("EmbedBatch succeeded against a failing server")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("EmbedBatch returned after %v, past its deadline", elapsed)
+	}
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"net/http"
+)
+
+// AddRouter registers a named router, with its own dataset and embedder, to
+// be served alongside the default router at /v1/routers/{name}/route and
+// /v1/routers/{name}/batch. Routers must be added before serving.
+func (s *Server) AddRouter(name string, router *Server) {
+	if s.routers == nil {
+		s.routers = make(map[string]*Server)
+	}
+	s.routers[name] = router
+}
+
+// RequireAuth requires the HTTP and gRPC routing endpoints of the server to
+// be called with an API key known to auth. It must be called before serving.
+func (s *Server) RequireAuth(auth *Authenticator) {
+	s.auth = auth
+}
+
+// EnableAdmin serves the admin endpoints, which must be called with the given
+// admin key. It must be called before serving.
+func (s *Server) EnableAdmin(adminKey string) {
+	s.adminKey = adminKey
+}
+
+// router returns the router with the given name, or the default router if the
+// name is empty.
+func (s *Server) router(name string) (*Server, bool) {
+	if name == "" {
+		return s, true
+	}
+	router, ok := s.routers[name]
+	return router, ok
+}
+
+// namedRouterHandler serves the handler of the router named in the request
+// path.
+func (s *Server) namedRouterHandler(handler func(router *Server) http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		router, ok := s.routers[name]
+		if !ok {
+			http.Error(w, "unknown router: "+name, http.StatusNotFound)
+			return
+		}
+		handler(router)(w, r)
+	}
+}
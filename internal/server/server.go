@@ -3,21 +3,18 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"math"
 	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
 
-	"github.com/pulzeai-oss/knn-router/internal/scorespb"
-	"github.com/pulzeai-oss/knn-router/internal/teipb"
-	qdrant "github.com/qdrant/go-client/qdrant"
-	bolt "go.etcd.io/bbolt"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"google.golang.org/grpc"
-	"google.golang.org/protobuf/proto"
-)
-
-const (
-	PointsCollection = "main"
+	"google.golang.org/grpc/health"
 )
 
 type TruncateStrategy uint8
@@ -29,9 +26,36 @@ const (
 	Ends
 )
 
+func (t TruncateStrategy) String() string {
+	switch t {
+	case Head:
+		return "head"
+	case Tail:
+		return "tail"
+	case Middle:
+		return "middle"
+	case Ends:
+		return "ends"
+	}
+	return fmt.Sprintf("unknown(%d)", uint8(t))
+}
+
 type Request struct {
 	Query            string           `json:"query"`
 	TruncateStrategy TruncateStrategy `json:"truncate_strategy"`
+	Weighting        *Weighting       `json:"weighting,omitempty"`
+	TopK             *int             `json:"top_k,omitempty"`
+	MinSimilarity    *float32         `json:"min_similarity,omitempty"`
+	AllowedTargets   []string         `json:"allowed_targets,omitempty"`
+	ExcludedTargets  []string         `json:"excluded_targets,omitempty"`
+	// Messages is a conversation to route, instead of the query.
+	Messages      []ChatMessage  `json:"messages,omitempty"`
+	TurnSelection *TurnSelection `json:"turn_selection,omitempty"`
+	// NoCache bypasses the embedding cache.
+	NoCache bool `json:"no_cache,omitempty"`
+	// Filter restricts the nearest neighbors to the points whose payload
+	// matches it.
+	Filter *Filter `json:"filter,omitempty"`
 }
 
 type Score struct {
@@ -43,41 +67,122 @@ type Hit struct {
 	ID         string  `json:"id"`
 	Category   string  `json:"category"`
 	Similarity float32 `json:"similarity"`
+	Weight     float32 `json:"weight"`
+}
+
+// Decision is the target chosen for a query.
+type Decision struct {
+	Target string  `json:"target"`
+	Score  float32 `json:"score"`
+	// Margin is the difference between the scores of the target and the
+	// runner-up.
+	Margin     float32 `json:"margin"`
+	Alternates []Score `json:"alternates"`
 }
 
 type Response struct {
-	Hits   []Hit   `json:"hits"`
-	Scores []Score `json:"scores"`
+	Hits              []Hit     `json:"hits"`
+	Scores            []Score   `json:"scores"`
+	Decision          *Decision `json:"decision"`
+	Weighting         Weighting `json:"weighting"`
+	OutOfDistribution bool      `json:"out_of_distribution"`
+	TopK              int       `json:"top_k"`
+	MinSimilarity     float32   `json:"min_similarity"`
+}
+
+type BatchRequest struct {
+	Queries []Request `json:"queries"`
+}
+
+type BatchResult struct {
+	*Response
+	Error string `json:"error,omitempty"`
+}
+
+type BatchResponse struct {
+	Results []BatchResult `json:"results"`
 }
 
 type Server struct {
-	embedClient       teipb.EmbedClient
-	tokenizeClient    teipb.TokenizeClient
-	pointsClient      qdrant.PointsClient
-	DB                *bolt.DB
+	embedder          Embedder
+	newIndex          IndexFactory
+	dbPath            string
+	collection        string
+	data              *dataset
+	dataMu            sync.RWMutex
+	reloadMu          sync.Mutex
 	topK              int
+	maxTopK           int
+	weighting         Weighting
+	turnSelection     TurnSelection
+	minSimilarity     float32
+	minNeighbors      int
+	fallback          []Score
+	alternates        int
+	upstreams         map[string]Upstream
+	timeouts          Timeouts
+	preload           bool
+	routers           map[string]*Server
+	auth              *Authenticator
+	adminKey          string
+	draining          atomic.Bool
+	serveMu           sync.Mutex
+	httpServer        *http.Server
+	grpcServer        *grpc.Server
+	healthServer      *health.Server
 	maxSequenceLength int
+	maxBatchSize      int
 }
 
 func NewServer(
-	embedConn *grpc.ClientConn,
-	qdrantConn *grpc.ClientConn,
-	DB *bolt.DB,
+	embedder Embedder,
+	newIndex IndexFactory,
+	dbPath string,
+	collection string,
 	topK int,
-	maxSequenceLength int,
-) *Server {
-	return &Server{
-		embedClient:       teipb.NewEmbedClient(embedConn),
-		tokenizeClient:    teipb.NewTokenizeClient(embedConn),
-		pointsClient:      qdrant.NewPointsClient(qdrantConn),
-		DB:                DB,
+	maxTopK int,
+	weighting Weighting,
+	turnSelection TurnSelection,
+	minSimilarity float32,
+	minNeighbors int,
+	fallback []Score,
+	alternates int,
+	upstreams map[string]Upstream,
+	timeouts Timeouts,
+	preload bool,
+) (*Server, error) {
+	info := embedder.Info()
+	if info.MaxInputLength < 1 {
+		return nil, fmt.Errorf("embedding model has invalid maximum input length %d", info.MaxInputLength)
+	}
+	s := &Server{
+		embedder:          embedder,
+		newIndex:          newIndex,
+		dbPath:            dbPath,
+		collection:        collection,
 		topK:              topK,
-		maxSequenceLength: maxSequenceLength,
+		maxTopK:           maxTopK,
+		weighting:         weighting,
+		turnSelection:     turnSelection,
+		minSimilarity:     minSimilarity,
+		minNeighbors:      minNeighbors,
+		fallback:          fallback,
+		alternates:        alternates,
+		upstreams:         upstreams,
+		timeouts:          timeouts,
+		preload:           preload,
+		maxSequenceLength: info.MaxInputLength,
+		maxBatchSize:      max(info.MaxBatchSize, 1),
 	}
+	d, err := s.openDataset()
+	if err != nil {
+		return nil, err
+	}
+	s.swap(d)
+	return s, nil
 }
 
 func (s *Server) handler(w http.ResponseWriter, r *http.Request) {
-	// TODO (jeev): Switch to GRPC server
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		http.Error(w, "failed to read request body", http.StatusBadRequest)
@@ -92,12 +197,17 @@ func (s *Server) handler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "failed to parse request body", http.StatusBadRequest)
 		return
 	}
-	if payload.Query == "" {
-		http.Error(w, "query is required", http.StatusBadRequest)
+	applyKeyDefaults(r.Context(), &payload)
+	if err := s.validate(&payload); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	res, err := s.query(r.Context(), &payload)
+	if errors.Is(err, ErrNoTargets) || errors.Is(err, ErrFilterUnsupported) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, "failed to retrieve scores", http.StatusInternalServerError)
 		return
@@ -109,36 +219,144 @@ func (s *Server) handler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (s *Server) batchHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	// Parse the payload from the request body
+	var payload BatchRequest
+	err = json.Unmarshal(body, &payload)
+	if err != nil {
+		http.Error(w, "failed to parse request body", http.StatusBadRequest)
+		return
+	}
+	if len(payload.Queries) == 0 {
+		http.Error(w, "queries are required", http.StatusBadRequest)
+		return
+	}
+	if !chargeBatch(w, r, len(payload.Queries)) {
+		return
+	}
+	for i := range payload.Queries {
+		if payload.Queries[i].TruncateStrategy == 0 {
+			payload.Queries[i].TruncateStrategy = Middle
+		}
+		applyKeyDefaults(r.Context(), &payload.Queries[i])
+	}
+
+	res := BatchResponse{Results: s.queryBatch(r.Context(), payload.Queries)}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// validate checks a request for errors that are the fault of the caller.
+func (s *Server) validate(req *Request) error {
+	if req.Query == "" && len(req.Messages) == 0 {
+		return fmt.Errorf("query or messages is required")
+	}
+	if req.Query != "" && len(req.Messages) > 0 {
+		return fmt.Errorf("only one of query and messages may be given")
+	}
+	if len(req.Messages) > 0 {
+		turnSelection := s.turnSelection.Override(req.TurnSelection)
+		if err := turnSelection.Validate(); err != nil {
+			return fmt.Errorf("invalid turn selection: %v", err)
+		}
+		if _, err := turnSelection.Texts(req.Messages); err != nil {
+			return err
+		}
+	}
+	if req.TopK != nil && (*req.TopK < 1 || *req.TopK > s.maxTopK) {
+		return fmt.Errorf("top_k must be between 1 and %d", s.maxTopK)
+	}
+	if req.MinSimilarity != nil && (*req.MinSimilarity < -1 || *req.MinSimilarity > 1) {
+		return fmt.Errorf("min_similarity must be between -1 and 1")
+	}
+	if err := s.weighting.Override(req.Weighting).Validate(); err != nil {
+		return fmt.Errorf("invalid weighting: %v", err)
+	}
+	if req.Filter != nil {
+		if err := req.Filter.Validate(); err != nil {
+			return fmt.Errorf("invalid filter: %v", err)
+		}
+	}
+	return nil
+}
+
+// topKFor returns the number of nearest neighbors to aggregate for the
+// request.
+func (s *Server) topKFor(req *Request) int {
+	if req.TopK != nil {
+		return *req.TopK
+	}
+	return s.topK
+}
+
+// minSimilarityFor returns the similarity below which neighbors are ignored
+// for the request.
+func (s *Server) minSimilarityFor(req *Request) float32 {
+	if req.MinSimilarity != nil {
+		return *req.MinSimilarity
+	}
+	return s.minSimilarity
+}
+
+// texts returns the texts to embed for the request: its query, or the turns
+// of its messages selected by the turn selection.
+func (s *Server) texts(req *Request) ([]string, error) {
+	if len(req.Messages) == 0 {
+		return []string{req.Query}, nil
+	}
+	return s.turnSelection.Override(req.TurnSelection).Texts(req.Messages)
+}
+
 func (s *Server) sanitizeQuery(
 	ctx context.Context,
 	req *Request,
+	text string,
 ) (string, error) {
-	encodeResp, err := s.tokenizeClient.Tokenize(ctx, &teipb.EncodeRequest{Inputs: req.Query})
+	start := time.Now()
+	ctx, cancel := s.timeouts.stageContext(ctx, stageTokenize)
+	defer cancel()
+	tokens, err := s.embedder.Tokenize(ctx, text)
+	observeStage(stageTokenize, start)
 	if err != nil {
 		return "", fmt.Errorf("failed to tokenize query: %v", err)
 	}
-	numTokens := len(encodeResp.GetTokens())
+	return s.truncate(req, text, tokens)
+}
+
+func (s *Server) truncate(req *Request, text string, tokens []Token) (string, error) {
+	numTokens := len(tokens)
 	if numTokens <= s.maxSequenceLength {
-		return req.Query, nil
+		return text, nil
 	}
+	truncationsTotal.WithLabelValues(req.TruncateStrategy.String()).Inc()
 
 	switch req.TruncateStrategy {
 	case Head:
-		startToken := encodeResp.GetTokens()[numTokens-s.maxSequenceLength]
-		return req.Query[*startToken.Start:], nil
+		startToken := tokens[numTokens-s.maxSequenceLength]
+		return text[startToken.Start:], nil
 	case Tail:
-		endToken := encodeResp.GetTokens()[s.maxSequenceLength-1]
-		return req.Query[:*endToken.Stop], nil
+		endToken := tokens[s.maxSequenceLength-1]
+		return text[:endToken.Stop], nil
 	case Middle:
 		offset := s.maxSequenceLength / 2
-		startTruncateToken := encodeResp.GetTokens()[offset]
-		endTruncateToken := encodeResp.GetTokens()[numTokens+offset-s.maxSequenceLength-1]
-		return req.Query[:*startTruncateToken.Start] + req.Query[*endTruncateToken.Stop:], nil
+		startTruncateToken := tokens[offset]
+		endTruncateToken := tokens[numTokens+offset-s.maxSequenceLength-1]
+		return text[:startTruncateToken.Start] + text[endTruncateToken.Stop:], nil
 	case Ends:
 		offset := (numTokens - s.maxSequenceLength) / 2
-		startToken := encodeResp.GetTokens()[offset]
-		endToken := encodeResp.GetTokens()[offset+s.maxSequenceLength-1]
-		return req.Query[*startToken.Start:*endToken.Stop], nil
+		startToken := tokens[offset]
+		endToken := tokens[offset+s.maxSequenceLength-1]
+		return text[startToken.Start:endToken.Stop], nil
 	}
 
 	return "", fmt.Errorf("unsupported truncate strategy: %v", req.TruncateStrategy)
@@ -148,86 +366,397 @@ func (s *Server) query(
 	ctx context.Context,
 	req *Request,
 ) (*Response, error) {
-	query, err := s.sanitizeQuery(ctx, req)
+	d, release := s.acquire()
+	defer release()
+
+	if req.NoCache {
+		ctx = WithCacheBypass(ctx)
+	}
+	texts, err := s.texts(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to sanitize query: %v", err)
+		return nil, err
 	}
-	embedResp, err := s.embedClient.Embed(ctx, &teipb.EmbedRequest{Inputs: query, Truncate: true})
+	queries := make([]string, len(texts))
+	for i, text := range texts {
+		queries[i], err = s.sanitizeQuery(ctx, req, text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sanitize query: %v", err)
+		}
+	}
+	start := time.Now()
+	embedCtx, cancel := s.timeouts.stageContext(ctx, stageEmbed)
+	defer cancel()
+	vector, err := s.embed(embedCtx, queries)
+	observeStage(stageEmbed, start)
 	if err != nil {
 		return nil, fmt.Errorf("failed to compute embedding: %v", err)
 	}
 
-	search, err := s.pointsClient.Search(ctx, &qdrant.SearchPoints{
-		CollectionName: PointsCollection,
-		Vector:         embedResp.GetEmbeddings(),
-		Limit:          uint64(s.topK),
-		WithVectors: &qdrant.WithVectorsSelector{
-			SelectorOptions: &qdrant.WithVectorsSelector_Enable{Enable: false},
-		},
-		WithPayload: &qdrant.WithPayloadSelector{
-			SelectorOptions: &qdrant.WithPayloadSelector_Enable{Enable: false},
-		},
-	})
+	start = time.Now()
+	searchCtx, cancel := s.timeouts.stageContext(ctx, stageSearch)
+	defer cancel()
+	neighbors, err := d.index.Search(searchCtx, vector, s.topKFor(req), req.Filter)
+	observeStage(stageSearch, start)
+	if errors.Is(err, ErrFilterUnsupported) {
+		return nil, err
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to search for nearest neighbors: %v", err)
 	}
 
-	// Initialize response
-	var res Response
-
-	// Aggregate scores from nearest neighbors
-	var weightSum float32
-	scoresSum := make(map[string]float32)
-	for _, pt := range search.GetResult() {
-		uid := pt.GetId().GetUuid()
-		weight := pt.GetScore()
-		weightSum += weight
-		// Lookup target scores in DB for given UID
-		err = s.DB.View(func(tx *bolt.Tx) error {
-			b := tx.Bucket([]byte(PointsCollection))
-			v := b.Get([]byte(uid))
-			if v == nil {
-				return fmt.Errorf("could not find targets for nearest neighbor UID %s", uid)
+	start = time.Now()
+	var res *Response
+	err = d.view(func(aggregate aggregateFunc) error {
+		res, err = s.aggregate(aggregate, req, neighbors)
+		return err
+	})
+	observeStage(stageScoreLookup, start)
+	if err != nil {
+		return nil, err
+	}
+	observeResponse(res)
+	return res, nil
+}
+
+// embed computes the embedding of a single text, or the mean embedding of
+// several texts in batches of at most maxBatchSize.
+func (s *Server) embed(ctx context.Context, texts []string) ([]float32, error) {
+	if len(texts) == 1 {
+		return s.embedder.Embed(ctx, texts[0])
+	}
+	var vectors [][]float32
+	for start := 0; start < len(texts); start += s.maxBatchSize {
+		embeddings, err := s.embedder.EmbedBatch(ctx, texts[start:min(start+s.maxBatchSize, len(texts))])
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, embeddings...)
+	}
+	return meanEmbedding(vectors), nil
+}
+
+// queryBatch routes each of the given requests, returning results in input
+// order. Failures are reported per request rather than failing the batch.
+func (s *Server) queryBatch(ctx context.Context, reqs []Request) []BatchResult {
+	d, release := s.acquire()
+	defer release()
+
+	results := make([]BatchResult, len(reqs))
+	fail := func(i int, err error) {
+		results[i] = BatchResult{Error: err.Error()}
+	}
+
+	// Select the texts to embed for all queries
+	type input struct {
+		req   int
+		text  string
+		query string
+	}
+	var inputs []input
+	for i := range reqs {
+		if err := s.validate(&reqs[i]); err != nil {
+			fail(i, err)
+			continue
+		}
+		texts, err := s.texts(&reqs[i])
+		if err != nil {
+			fail(i, err)
+			continue
+		}
+		for _, text := range texts {
+			inputs = append(inputs, input{req: i, text: text})
+		}
+	}
+	failInput := func(k int, err error) {
+		fail(inputs[k].req, err)
+	}
+	failed := func(k int) bool {
+		return results[inputs[k].req].Error != ""
+	}
+	bypassCache := func(ctx context.Context, chunk []int) context.Context {
+		for _, k := range chunk {
+			if reqs[inputs[k].req].NoCache {
+				return WithCacheBypass(ctx)
 			}
-			var payload scorespb.Point
-			err = proto.Unmarshal(v, &payload)
+		}
+		return ctx
+	}
+	pendingInputs := make([]int, len(inputs))
+	for k := range inputs {
+		pendingInputs[k] = k
+	}
+
+	// Tokenize and truncate all texts
+	pendingInputs = s.forEachChunk(pendingInputs, failInput, func(chunk []int) error {
+		texts := make([]string, len(chunk))
+		for j, k := range chunk {
+			texts[j] = inputs[k].text
+		}
+		start := time.Now()
+		ctx, cancel := s.timeouts.stageContext(bypassCache(ctx, chunk), stageTokenize)
+		defer cancel()
+		tokens, err := s.embedder.TokenizeBatch(ctx, texts)
+		observeStage(stageTokenize, start)
+		if err != nil {
+			return fmt.Errorf("failed to sanitize query: failed to tokenize query: %v", err)
+		}
+		for j, k := range chunk {
+			inputs[k].query, err = s.truncate(&reqs[inputs[k].req], inputs[k].text, tokens[j])
 			if err != nil {
-				return err
+				failInput(k, fmt.Errorf("failed to sanitize query: %v", err))
 			}
-			res.Hits = append(
-				res.Hits,
-				Hit{
-					ID:         uid,
-					Category:   payload.GetCategory(),
-					Similarity: weight,
-				},
-			)
-			for _, score := range payload.GetScores() {
-				scoresSum[score.GetTarget()] += score.GetScore() * weight
-			}
-			return nil
-		})
+		}
+		return nil
+	})
+	pendingInputs = slices.DeleteFunc(pendingInputs, failed)
+
+	// Compute embeddings for all sanitized texts
+	embeddings := make([][]float32, len(inputs))
+	pendingInputs = s.forEachChunk(pendingInputs, failInput, func(chunk []int) error {
+		texts := make([]string, len(chunk))
+		for j, k := range chunk {
+			texts[j] = inputs[k].query
+		}
+		start := time.Now()
+		ctx, cancel := s.timeouts.stageContext(bypassCache(ctx, chunk), stageEmbed)
+		defer cancel()
+		batch, err := s.embedder.EmbedBatch(ctx, texts)
+		observeStage(stageEmbed, start)
 		if err != nil {
-			return nil, fmt.Errorf(
-				"failed to retrieve targets for nearest neighbor UID %s: %v",
-				uid,
-				err,
-			)
-		}
-	}
-	// Normalize the accumulated scores by dividing by the sum of weighted distances
-	for target, score := range scoresSum {
-		normalizedScore := float32(math.Round(float64(score/weightSum)*100)) / 100
-		res.Scores = append(
-			res.Scores,
-			Score{Target: target, Score: normalizedScore},
+			return fmt.Errorf("failed to compute embedding: %v", err)
+		}
+		for j, k := range chunk {
+			embeddings[k] = batch[j]
+		}
+		return nil
+	})
+	pendingInputs = slices.DeleteFunc(pendingInputs, failed)
+
+	// Combine the embeddings of the texts of each query
+	turns := make([][][]float32, len(reqs))
+	var pending []int
+	for _, k := range pendingInputs {
+		i := inputs[k].req
+		if turns[i] == nil {
+			pending = append(pending, i)
+		}
+		turns[i] = append(turns[i], embeddings[k])
+	}
+	if len(pending) == 0 {
+		return results
+	}
+	vectors := make([][]float32, len(reqs))
+	for _, i := range pending {
+		vectors[i] = meanEmbedding(turns[i])
+	}
+
+	// Search for nearest neighbors of all embeddings at once, with the largest
+	// k in the batch, and keep the nearest neighbors of each query up to its
+	// own k
+	search := func() ([][]Neighbor, error) {
+		searches := make([][]float32, len(pending))
+		filters := make([]*Filter, len(pending))
+		var limit int
+		for j, i := range pending {
+			searches[j] = vectors[i]
+			filters[j] = reqs[i].Filter
+			limit = max(limit, s.topKFor(&reqs[i]))
+		}
+		start := time.Now()
+		searchCtx, cancel := s.timeouts.stageContext(ctx, stageSearch)
+		defer cancel()
+		neighbors, err := d.index.SearchBatch(searchCtx, searches, limit, filters)
+		observeStage(stageSearch, start)
+		return neighbors, err
+	}
+	neighbors, err := search()
+	if errors.Is(err, ErrFilterUnsupported) {
+		// Fail the filtered queries, and search again for the others
+		unfiltered := pending[:0]
+		for _, i := range pending {
+			if reqs[i].Filter != nil {
+				fail(i, err)
+			} else {
+				unfiltered = append(unfiltered, i)
+			}
+		}
+		pending = unfiltered
+		if len(pending) == 0 {
+			return results
+		}
+		neighbors, err = search()
+	}
+	if err != nil {
+		for _, i := range pending {
+			fail(i, fmt.Errorf("failed to search for nearest neighbors: %v", err))
+		}
+		return results
+	}
+
+	// Lookup target scores for all nearest neighbors in a single transaction
+	start := time.Now()
+	err = d.view(func(aggregate aggregateFunc) error {
+		for j, batch := range neighbors {
+			req := &reqs[pending[j]]
+			batch = batch[:min(len(batch), s.topKFor(req))]
+			res, err := s.aggregate(aggregate, req, batch)
+			if err != nil {
+				fail(pending[j], err)
+				continue
+			}
+			observeResponse(res)
+			results[pending[j]] = BatchResult{Response: res}
+		}
+		return nil
+	})
+	observeStage(stageScoreLookup, start)
+	if err != nil {
+		for _, i := range pending {
+			fail(i, err)
+		}
+	}
+	return results
+}
+
+// forEachChunk calls fn with chunks of at most maxBatchSize indices, failing
+// every index in a chunk for which fn returns an error. It returns the indices
+// that did not fail.
+func (s *Server) forEachChunk(
+	indices []int,
+	fail func(int, error),
+	fn func(chunk []int) error,
+) []int {
+	var ok []int
+	for start := 0; start < len(indices); start += s.maxBatchSize {
+		chunk := indices[start:min(start+s.maxBatchSize, len(indices))]
+		if err := fn(chunk); err != nil {
+			for _, i := range chunk {
+				fail(i, err)
+			}
+			continue
+		}
+		ok = append(ok, chunk...)
+	}
+	return ok
+}
+
+// aggregate computes the weighted average score for each target over the
+// given nearest neighbors. If too few neighbors are similar enough to the
+// query, the query is out of distribution, and the fallback scores are
+// returned instead.
+func (s *Server) aggregate(aggregate aggregateFunc, req *Request, neighbors []Neighbor) (*Response, error) {
+	if len(neighbors) > 0 {
+		topSimilarity.Observe(float64(neighbors[0].Similarity))
+	}
+	minSimilarity := s.minSimilarityFor(req)
+	neighbors = slices.DeleteFunc(slices.Clone(neighbors), func(neighbor Neighbor) bool {
+		return neighbor.Similarity < minSimilarity
+	})
+
+	res, err := aggregate(neighbors, s.weighting.Override(req.Weighting))
+	if err != nil {
+		return nil, err
+	}
+	res.TopK = s.topKFor(req)
+	res.MinSimilarity = minSimilarity
+	if len(neighbors) < s.minNeighbors {
+		res.OutOfDistribution = true
+		res.Scores = slices.Clone(s.fallback)
+	}
+	res.Scores, err = FilterTargets(res.Scores, req.AllowedTargets, req.ExcludedTargets)
+	if err != nil {
+		return nil, err
+	}
+	SortScores(res.Scores)
+	res.Decision = Decide(res.Scores, s.alternates)
+	roundScores(res)
+	return res, nil
+}
+
+// ListenAndServe serves the HTTP endpoints on the given address until the
+// server is shut down.
+func (s *Server) ListenAndServe(bindAddr string, readTimeout, writeTimeout, idleTimeout time.Duration) error {
+	mux := http.NewServeMux()
+	// Routing endpoints require an API key, if auth is enabled
+	route := func(endpoint string, handler http.HandlerFunc) {
+		mux.HandleFunc(endpoint, instrumentHandler(endpoint, s.auth.middleware(handler, httpError)))
+	}
+	route("/", s.handler)
+	route("/batch", s.batchHandler)
+	route("/v1/routers/{name}/route", s.namedRouterHandler(func(router *Server) http.HandlerFunc {
+		return router.handler
+	}))
+	route("/v1/routers/{name}/batch", s.namedRouterHandler(func(router *Server) http.HandlerFunc {
+		return router.batchHandler
+	}))
+	mux.HandleFunc("/healthz", s.healthzHandler)
+	mux.HandleFunc("/readyz", s.readyzHandler)
+	// Admin endpoints are only served with an admin key
+	if s.adminKey != "" {
+		mux.HandleFunc("/admin/reload", adminMiddleware(s.adminKey, s.reloadHandler))
+	}
+	if len(s.upstreams) > 0 {
+		mux.HandleFunc(
+			"/v1/chat/completions",
+			instrumentHandler("/v1/chat/completions", s.auth.middleware(s.chatCompletionsHandler, chatError)),
 		)
 	}
-	return &res, nil
+	mux.Handle("/metrics", promhttp.Handler())
+
+	s.serveMu.Lock()
+	s.httpServer = &http.Server{
+		Addr:         bindAddr,
+		Handler:      mux,
+		ReadTimeout:  readTimeout,
+		WriteTimeout: writeTimeout,
+		IdleTimeout:  idleTimeout,
+	}
+	s.serveMu.Unlock()
+	if err := s.httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
 }
 
-func (s *Server) ListenAndServe(bindAddr string) error {
-	// TODO (jeev): Add prometheus metrics
-	http.HandleFunc("/", s.handler)
-	return http.ListenAndServe(bindAddr, nil)
+// Shutdown stops reporting ready, keeps serving for the given delay so that
+// load balancers stop sending new requests, and then drains in-flight HTTP
+// and gRPC requests until ctx is done, after which remaining requests are
+// cut off.
+func (s *Server) Shutdown(ctx context.Context, delay time.Duration) error {
+	s.draining.Store(true)
+	s.serveMu.Lock()
+	httpServer, grpcServer, healthServer := s.httpServer, s.grpcServer, s.healthServer
+	s.serveMu.Unlock()
+	if healthServer != nil {
+		healthServer.Shutdown()
+	}
+
+	select {
+	case <-time.After(delay):
+	case <-ctx.Done():
+	}
+
+	var wg sync.WaitGroup
+	var err error
+	if httpServer != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err = httpServer.Shutdown(ctx)
+		}()
+	}
+	if grpcServer != nil {
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			grpcServer.Stop()
+		}
+	}
+	wg.Wait()
+	return err
 }
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"context"
+
+	"github.com/pulzeai-oss/knn-router/internal/teipb"
+	"google.golang.org/grpc"
+)
+
+// TEIEmbedder is an Embedder backed by the gRPC API of HuggingFace Text
+// Embeddings Inference.
+type TEIEmbedder struct {
+	infoClient     teipb.InfoClient
+	embedClient    teipb.EmbedClient
+	tokenizeClient teipb.TokenizeClient
+	info           EmbedderInfo
+}
+
+func NewTEIEmbedder(ctx context.Context, conn *grpc.ClientConn) (*TEIEmbedder, error) {
+	infoClient := teipb.NewInfoClient(conn)
+	infoResp, err := infoClient.Info(ctx, &teipb.InfoRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return &TEIEmbedder{
+		infoClient:     infoClient,
+		embedClient:    teipb.NewEmbedClient(conn),
+		tokenizeClient: teipb.NewTokenizeClient(conn),
+		info: EmbedderInfo{
+			ModelID:        infoResp.GetModelId(),
+			MaxInputLength: int(infoResp.GetMaxInputLength()),
+			MaxBatchSize:   int(infoResp.GetMaxClientBatchSize()),
+		},
+	}, nil
+}
+
+func (e *TEIEmbedder) Info() EmbedderInfo {
+	return e.info
+}
+
+func (e *TEIEmbedder) Check(ctx context.Context) error {
+	_, err := e.infoClient.Info(ctx, &teipb.InfoRequest{})
+	return err
+}
+
+func (e *TEIEmbedder) Tokenize(ctx context.Context, input string) ([]Token, error) {
+	encodeResp, err := e.tokenizeClient.Tokenize(ctx, &teipb.EncodeRequest{Inputs: input})
+	if err != nil {
+		return nil, err
+	}
+	return fromTEITokens(encodeResp.GetTokens()), nil
+}
+
+func (e *TEIEmbedder) TokenizeBatch(ctx context.Context, inputs []string) ([][]Token, error) {
+	stream, err := e.tokenizeClient.TokenizeStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reqs := make([]*teipb.EncodeRequest, len(inputs))
+	for i, input := range inputs {
+		reqs[i] = &teipb.EncodeRequest{Inputs: input}
+	}
+	encodeResps, err := exchange(stream, reqs)
+	if err != nil {
+		return nil, err
+	}
+	tokens := make([][]Token, len(encodeResps))
+	for i, encodeResp := range encodeResps {
+		tokens[i] = fromTEITokens(encodeResp.GetTokens())
+	}
+	return tokens, nil
+}
+
+func (e *TEIEmbedder) Embed(ctx context.Context, input string) ([]float32, error) {
+	embedResp, err := e.embedClient.Embed(ctx, &teipb.EmbedRequest{Inputs: input, Truncate: true})
+	if err != nil {
+		return nil, err
+	}
+	return embedResp.GetEmbeddings(), nil
+}
+
+func (e *TEIEmbedder) EmbedBatch(ctx context.Context, inputs []string) ([][]float32, error) {
+	stream, err := e.embedClient.EmbedStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reqs := make([]*teipb.EmbedRequest, len(inputs))
+	for i, input := range inputs {
+		reqs[i] = &teipb.EmbedRequest{Inputs: input, Truncate: true}
+	}
+	embedResps, err := exchange(stream, reqs)
+	if err != nil {
+		return nil, err
+	}
+	vectors := make([][]float32, len(embedResps))
+	for i, embedResp := range embedResps {
+		vectors[i] = embedResp.GetEmbeddings()
+	}
+	return vectors, nil
+}
+
+func fromTEITokens(tokens []*teipb.SimpleToken) []Token {
+	out := make([]Token, len(tokens))
+	for i, token := range tokens {
+		out[i] = Token{Start: int(token.GetStart()), Stop: int(token.GetStop())}
+	}
+	return out
+}
+
+// exchange sends all requests on a bidirectional stream while concurrently
+// receiving one response per request, in order.
+func exchange[Req, Resp any](stream interface {
+	Send(Req) error
+	Recv() (Resp, error)
+	CloseSend() error
+}, reqs []Req) ([]Resp, error) {
+	sendErr := make(chan error, 1)
+	go func() {
+		for _, req := range reqs {
+			if err := stream.Send(req); err != nil {
+				sendErr <- err
+				return
+			}
+		}
+		sendErr <- stream.CloseSend()
+	}()
+	resps := make([]Resp, 0, len(reqs))
+	for range reqs {
+		resp, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		resps = append(resps, resp)
+	}
+	if err := <-sendErr; err != nil {
+		return nil, err
+	}
+	return resps, nil
+}
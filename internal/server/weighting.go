@@ -0,0 +1,108 @@
+package server
+
+import (
+	"fmt"
+	"math"
+)
+
+type WeightingKernel string
+
+const (
+	// SimilarityKernel weights each neighbor by its raw similarity.
+	SimilarityKernel WeightingKernel = "similarity"
+	// SoftmaxKernel weights each neighbor by the softmax of its similarity,
+	// divided by the temperature.
+	SoftmaxKernel WeightingKernel = "softmax"
+	// RankKernel weights the n-th nearest neighbor by decay^n.
+	RankKernel WeightingKernel = "rank"
+	// UniformKernel weights all neighbors equally.
+	UniformKernel WeightingKernel = "uniform"
+	// PowerKernel weights each neighbor by its similarity raised to the
+	// exponent.
+	PowerKernel WeightingKernel = "power"
+)
+
+var UniformWeighting = Weighting{Kernel: UniformKernel}
+
+// Weighting configures how neighbors are weighted when aggregating target
+// scores. Only the parameter of the selected kernel is used.
+type Weighting struct {
+	Kernel      WeightingKernel `json:"kernel,omitempty"`
+	Temperature float32         `json:"temperature,omitempty"`
+	Decay       float32         `json:"decay,omitempty"`
+	Exponent    float32         `json:"exponent,omitempty"`
+}
+
+// Override returns the weighting with the non-zero fields of o applied, and
+// the parameters of the other kernels cleared.
+func (w Weighting) Override(o *Weighting) Weighting {
+	if o != nil {
+		if o.Kernel != "" {
+			w.Kernel = o.Kernel
+		}
+		if o.Temperature != 0 {
+			w.Temperature = o.Temperature
+		}
+		if o.Decay != 0 {
+			w.Decay = o.Decay
+		}
+		if o.Exponent != 0 {
+			w.Exponent = o.Exponent
+		}
+	}
+	res := Weighting{Kernel: w.Kernel}
+	switch w.Kernel {
+	case SoftmaxKernel:
+		res.Temperature = w.Temperature
+	case RankKernel:
+		res.Decay = w.Decay
+	case PowerKernel:
+		res.Exponent = w.Exponent
+	}
+	return res
+}
+
+func (w Weighting) Validate() error {
+	switch w.Kernel {
+	case SimilarityKernel, UniformKernel:
+	case SoftmaxKernel:
+		if w.Temperature <= 0 {
+			return fmt.Errorf("softmax temperature must be positive")
+		}
+	case RankKernel:
+		if w.Decay <= 0 || w.Decay > 1 {
+			return fmt.Errorf("rank decay must be in (0, 1]")
+		}
+	case PowerKernel:
+		if w.Exponent <= 0 {
+			return fmt.Errorf("power exponent must be positive")
+		}
+	default:
+		return fmt.Errorf("unsupported weighting kernel: %s", w.Kernel)
+	}
+	return nil
+}
+
+// Weights computes the weight of each neighbor, which must be ordered by
+// decreasing similarity.
+func (w Weighting) Weights(neighbors []Neighbor) []float32 {
+	weights := make([]float32, len(neighbors))
+	for i, neighbor := range neighbors {
+		switch w.Kernel {
+		case SimilarityKernel:
+			weights[i] = neighbor.Similarity
+		case SoftmaxKernel:
+			// Shift by the top similarity for numerical stability
+			weights[i] = float32(math.Exp(
+				float64((neighbor.Similarity - neighbors[0].Similarity) / w.Temperature),
+			))
+		case RankKernel:
+			weights[i] = float32(math.Pow(float64(w.Decay), float64(i)))
+		case UniformKernel:
+			weights[i] = 1
+		case PowerKernel:
+			weights[i] = float32(math.Pow(math.Max(float64(neighbor.Similarity), 0), float64(w.Exponent)))
+		}
+	}
+	return weights
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"math"
+	"testing"
+)
+
+// approxEqual reports whether got and want have the same length and
+// elements within 1e-5 of each other.
+func approxEqual(got, want []float32) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if math.Abs(float64(got[i]-want[i])) > 1e-5 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestWeights(t *testing.T) {
+	neighbors := []Neighbor{{ID: "a", Similarity: 0.9}, {ID: "b", Similarity: 0.5}, {ID: "c", Similarity: -0.2}}
+	for _, tt := range []struct {
+		name      string
+		weighting Weighting
+		want      []float32
+	}{
+		{
+			name:      "similarity",
+			weighting: Weighting{Kernel: SimilarityKernel},
+			want:      []float32{0.9, 0.5, -0.2},
+		},
+		{
+			name:      "softmax",
+			weighting: Weighting{Kernel: SoftmaxKernel, Temperature: 0.1},
+			want:      []float32{1, float32(math.Exp(-4)), float32(math.Exp(-11))},
+		},
+		{
+			name:      "rank",
+			weighting: Weighting{Kernel: RankKernel, Decay: 0.5},
+			want:      []float32{1, 0.5, 0.25},
+		},
+		{
+			name:      "uniform",
+			weighting: Weighting{Kernel: UniformKernel},
+			want:      []float32{1, 1, 1},
+		},
+		{
+			name:      "power clamps negative similarities",
+			weighting: Weighting{Kernel: PowerKernel, Exponent: 2},
+			want:      []float32{0.81, 0.25, 0},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.weighting.Weights(neighbors); !approxEqual(got, tt.want) {
+				t.Errorf("Weights() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNeighborWeights(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		neighbors []Neighbor
+		weighting Weighting
+		want      []float32
+		wantSum   float32
+	}{
+		{
+			name:      "kernel weights",
+			neighbors: []Neighbor{{ID: "a", Similarity: 0.6}, {ID: "b", Similarity: 0.2}},
+			weighting: Weighting{Kernel: SimilarityKernel},
+			want:      []float32{0.6, 0.2},
+			wantSum:   0.8,
+		},
+		{
+			name:      "negative similarity",
+			neighbors: []Neighbor{{ID: "a", Similarity: 0.6}, {ID: "b", Similarity: -0.2}},
+			weighting: Weighting{Kernel: SimilarityKernel},
+			want:      []float32{0.6, -0.2},
+			wantSum:   0.4,
+		},
+		{
+			name:      "zero sum falls back to uniform",
+			neighbors: []Neighbor{{ID: "a", Similarity: 0.5}, {ID: "b", Similarity: -0.5}},
+			weighting: Weighting{Kernel: SimilarityKernel},
+			want:      []float32{1, 1},
+			wantSum:   2,
+		},
+		{
+			name:      "zero power weights fall back to uniform",
+			neighbors: []Neighbor{{ID: "a", Similarity: -0.1}, {ID: "b", Similarity: -0.3}},
+			weighting: Weighting{Kernel: PowerKernel, Exponent: 4},
+			want:      []float32{1, 1},
+			wantSum:   2,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, sum := neighborWeights(tt.neighbors, tt.weighting)
+			if !approxEqual(got, tt.want) || !approxEqual([]float32{sum}, []float32{tt.wantSum}) {
+				t.Errorf("neighborWeights() = %v, %v, want %v, %v", got, sum, tt.want, tt.wantSum)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package store
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+const (
+	// PointsCollection is the default name of the Qdrant collection and of
+	// the Bolt bucket that hold the points.
+	PointsCollection = "main"
+
+	// CollectionsBucket maps the name of each points bucket to the vector
+	// index collection holding its points, so that the two are swapped
+	// together when the scores database is replaced.
+	CollectionsBucket = "collections"
+
+	// CategoryField is the payload field holding the category of a point.
+	CategoryField = "category"
+
+	// QdrantAPIKeyHeader is the metadata key that Qdrant reads API keys from.
+	QdrantAPIKeyHeader = "api-key"
+)
+
+// VectorsBucket returns the name of the bucket holding the embeddings of the
+// points in the given vector collection, for the local index.
+func VectorsBucket(collection string) string {
+	return "vectors/" + collection
+}
+
+// EncodeVector encodes an embedding as little-endian float32s, for storage in
+// a vectors bucket.
+func EncodeVector(vector []float32) []byte {
+	buf := make([]byte, 4*len(vector))
+	for i, x := range vector {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(x))
+	}
+	return buf
+}
+
+// DecodeVector decodes an embedding encoded with EncodeVector.
+func DecodeVector(buf []byte) ([]float32, error) {
+	if len(buf)%4 != 0 {
+		return nil, fmt.Errorf("invalid vector length %d", len(buf))
+	}
+	vector := make([]float32, len(buf)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return vector, nil
+}
@@ -735,19 +735,28 @@ var file_tei_proto_rawDesc = []byte{
 	0x04, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x13, 0x2e, 0x74, 0x65, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x49,
 	0x6e, 0x66, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x74, 0x65, 0x69,
 	0x2e, 0x76, 0x31, 0x2e, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
-	0x22, 0x03, 0x90, 0x02, 0x02, 0x32, 0x3d, 0x0a, 0x05, 0x45, 0x6d, 0x62, 0x65, 0x64, 0x12, 0x34,
+	0x22, 0x03, 0x90, 0x02, 0x02, 0x32, 0x7d, 0x0a, 0x05, 0x45, 0x6d, 0x62, 0x65, 0x64, 0x12, 0x34,
 	0x0a, 0x05, 0x45, 0x6d, 0x62, 0x65, 0x64, 0x12, 0x14, 0x2e, 0x74, 0x65, 0x69, 0x2e, 0x76, 0x31,
 	0x2e, 0x45, 0x6d, 0x62, 0x65, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x15, 0x2e,
 	0x74, 0x65, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x6d, 0x62, 0x65, 0x64, 0x52, 0x65, 0x73, 0x70,
-	0x6f, 0x6e, 0x73, 0x65, 0x32, 0x45, 0x0a, 0x08, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x69, 0x7a, 0x65,
-	0x12, 0x39, 0x0a, 0x08, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x69, 0x7a, 0x65, 0x12, 0x15, 0x2e, 0x74,
-	0x65, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x71, 0x75,
-	0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x74, 0x65, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x6e, 0x63,
-	0x6f, 0x64, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x38, 0x5a, 0x36, 0x67,
-	0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x70, 0x75, 0x6c, 0x7a, 0x65, 0x61,
-	0x69, 0x2d, 0x6f, 0x73, 0x73, 0x2f, 0x6b, 0x6e, 0x6e, 0x2d, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x72,
-	0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x74, 0x65, 0x69, 0x70, 0x62, 0x3b,
-	0x74, 0x65, 0x69, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3e, 0x0a, 0x0b, 0x45, 0x6d, 0x62, 0x65, 0x64, 0x53, 0x74, 0x72,
+	0x65, 0x61, 0x6d, 0x12, 0x14, 0x2e, 0x74, 0x65, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x6d, 0x62,
+	0x65, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x15, 0x2e, 0x74, 0x65, 0x69, 0x2e,
+	0x76, 0x31, 0x2e, 0x45, 0x6d, 0x62, 0x65, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x28, 0x01, 0x30, 0x01, 0x32, 0x8a, 0x01, 0x0a, 0x08, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x69, 0x7a,
+	0x65, 0x12, 0x39, 0x0a, 0x08, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x69, 0x7a, 0x65, 0x12, 0x15, 0x2e,
+	0x74, 0x65, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x74, 0x65, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x6e,
+	0x63, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x43, 0x0a, 0x0e,
+	0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x69, 0x7a, 0x65, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x12, 0x15,
+	0x2e, 0x74, 0x65, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x74, 0x65, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x45,
+	0x6e, 0x63, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x28, 0x01, 0x30,
+	0x01, 0x42, 0x38, 0x5a, 0x36, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
+	0x70, 0x75, 0x6c, 0x7a, 0x65, 0x61, 0x69, 0x2d, 0x6f, 0x73, 0x73, 0x2f, 0x6b, 0x6e, 0x6e, 0x2d,
+	0x72, 0x6f, 0x75, 0x74, 0x65, 0x72, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f,
+	0x74, 0x65, 0x69, 0x70, 0x62, 0x3b, 0x74, 0x65, 0x69, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x33,
 }
 
 var (
@@ -781,12 +790,16 @@ var file_tei_proto_depIdxs = []int32{
 	7, // 2: tei.v1.EncodeResponse.tokens:type_name -> tei.v1.SimpleToken
 	1, // 3: tei.v1.Info.Info:input_type -> tei.v1.InfoRequest
 	4, // 4: tei.v1.Embed.Embed:input_type -> tei.v1.EmbedRequest
-	6, // 5: tei.v1.Tokenize.Tokenize:input_type -> tei.v1.EncodeRequest
-	2, // 6: tei.v1.Info.Info:output_type -> tei.v1.InfoResponse
-	5, // 7: tei.v1.Embed.Embed:output_type -> tei.v1.EmbedResponse
-	8, // 8: tei.v1.Tokenize.Tokenize:output_type -> tei.v1.EncodeResponse
-	6, // [6:9] is the sub-list for method output_type
-	3, // [3:6] is the sub-list for method input_type
+	4, // 5: tei.v1.Embed.EmbedStream:input_type -> tei.v1.EmbedRequest
+	6, // 6: tei.v1.Tokenize.Tokenize:input_type -> tei.v1.EncodeRequest
+	6, // 7: tei.v1.Tokenize.TokenizeStream:input_type -> tei.v1.EncodeRequest
+	2, // 8: tei.v1.Info.Info:output_type -> tei.v1.InfoResponse
+	5, // 9: tei.v1.Embed.Embed:output_type -> tei.v1.EmbedResponse
+	5, // 10: tei.v1.Embed.EmbedStream:output_type -> tei.v1.EmbedResponse
+	8, // 11: tei.v1.Tokenize.Tokenize:output_type -> tei.v1.EncodeResponse
+	8, // 12: tei.v1.Tokenize.TokenizeStream:output_type -> tei.v1.EncodeResponse
+	8, // [8:13] is the sub-list for method output_type
+	3, // [3:8] is the sub-list for method input_type
 	3, // [3:3] is the sub-list for extension type_name
 	3, // [3:3] is the sub-list for extension extendee
 	0, // [0:3] is the sub-list for field type_name
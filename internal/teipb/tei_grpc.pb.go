@@ -109,7 +109,8 @@ var Info_ServiceDesc = grpc.ServiceDesc{
 }
 
 const (
-	Embed_Embed_FullMethodName = "/tei.v1.Embed/Embed"
+	Embed_Embed_FullMethodName       = "/tei.v1.Embed/Embed"
+	Embed_EmbedStream_FullMethodName = "/tei.v1.Embed/EmbedStream"
 )
 
 // EmbedClient is the client API for Embed service.
@@ -117,6 +118,7 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type EmbedClient interface {
 	Embed(ctx context.Context, in *EmbedRequest, opts ...grpc.CallOption) (*EmbedResponse, error)
+	EmbedStream(ctx context.Context, opts ...grpc.CallOption) (Embed_EmbedStreamClient, error)
 }
 
 type embedClient struct {
@@ -136,11 +138,43 @@ func (c *embedClient) Embed(ctx context.Context, in *EmbedRequest, opts ...grpc.
 	return out, nil
 }
 
+func (c *embedClient) EmbedStream(ctx context.Context, opts ...grpc.CallOption) (Embed_EmbedStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Embed_ServiceDesc.Streams[0], Embed_EmbedStream_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &embedEmbedStreamClient{stream}
+	return x, nil
+}
+
+type Embed_EmbedStreamClient interface {
+	Send(*EmbedRequest) error
+	Recv() (*EmbedResponse, error)
+	grpc.ClientStream
+}
+
+type embedEmbedStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *embedEmbedStreamClient) Send(m *EmbedRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *embedEmbedStreamClient) Recv() (*EmbedResponse, error) {
+	m := new(EmbedResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // EmbedServer is the server API for Embed service.
 // All implementations must embed UnimplementedEmbedServer
 // for forward compatibility
 type EmbedServer interface {
 	Embed(context.Context, *EmbedRequest) (*EmbedResponse, error)
+	EmbedStream(Embed_EmbedStreamServer) error
 	mustEmbedUnimplementedEmbedServer()
 }
 
@@ -151,6 +185,9 @@ type UnimplementedEmbedServer struct {
 func (UnimplementedEmbedServer) Embed(context.Context, *EmbedRequest) (*EmbedResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Embed not implemented")
 }
+func (UnimplementedEmbedServer) EmbedStream(Embed_EmbedStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method EmbedStream not implemented")
+}
 func (UnimplementedEmbedServer) mustEmbedUnimplementedEmbedServer() {}
 
 // UnsafeEmbedServer may be embedded to opt out of forward compatibility for this service.
@@ -182,6 +219,32 @@ func _Embed_Embed_Handler(srv interface{}, ctx context.Context, dec func(interfa
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Embed_EmbedStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(EmbedServer).EmbedStream(&embedEmbedStreamServer{stream})
+}
+
+type Embed_EmbedStreamServer interface {
+	Send(*EmbedResponse) error
+	Recv() (*EmbedRequest, error)
+	grpc.ServerStream
+}
+
+type embedEmbedStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *embedEmbedStreamServer) Send(m *EmbedResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *embedEmbedStreamServer) Recv() (*EmbedRequest, error) {
+	m := new(EmbedRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // Embed_ServiceDesc is the grpc.ServiceDesc for Embed service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -194,12 +257,20 @@ var Embed_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _Embed_Embed_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "EmbedStream",
+			Handler:       _Embed_EmbedStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
 	Metadata: "tei.proto",
 }
 
 const (
-	Tokenize_Tokenize_FullMethodName = "/tei.v1.Tokenize/Tokenize"
+	Tokenize_Tokenize_FullMethodName       = "/tei.v1.Tokenize/Tokenize"
+	Tokenize_TokenizeStream_FullMethodName = "/tei.v1.Tokenize/TokenizeStream"
 )
 
 // TokenizeClient is the client API for Tokenize service.
@@ -207,6 +278,7 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type TokenizeClient interface {
 	Tokenize(ctx context.Context, in *EncodeRequest, opts ...grpc.CallOption) (*EncodeResponse, error)
+	TokenizeStream(ctx context.Context, opts ...grpc.CallOption) (Tokenize_TokenizeStreamClient, error)
 }
 
 type tokenizeClient struct {
@@ -226,11 +298,43 @@ func (c *tokenizeClient) Tokenize(ctx context.Context, in *EncodeRequest, opts .
 	return out, nil
 }
 
+func (c *tokenizeClient) TokenizeStream(ctx context.Context, opts ...grpc.CallOption) (Tokenize_TokenizeStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Tokenize_ServiceDesc.Streams[0], Tokenize_TokenizeStream_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &tokenizeTokenizeStreamClient{stream}
+	return x, nil
+}
+
+type Tokenize_TokenizeStreamClient interface {
+	Send(*EncodeRequest) error
+	Recv() (*EncodeResponse, error)
+	grpc.ClientStream
+}
+
+type tokenizeTokenizeStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *tokenizeTokenizeStreamClient) Send(m *EncodeRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *tokenizeTokenizeStreamClient) Recv() (*EncodeResponse, error) {
+	m := new(EncodeResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // TokenizeServer is the server API for Tokenize service.
 // All implementations must embed UnimplementedTokenizeServer
 // for forward compatibility
 type TokenizeServer interface {
 	Tokenize(context.Context, *EncodeRequest) (*EncodeResponse, error)
+	TokenizeStream(Tokenize_TokenizeStreamServer) error
 	mustEmbedUnimplementedTokenizeServer()
 }
 
@@ -241,6 +345,9 @@ type UnimplementedTokenizeServer struct {
 func (UnimplementedTokenizeServer) Tokenize(context.Context, *EncodeRequest) (*EncodeResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Tokenize not implemented")
 }
+func (UnimplementedTokenizeServer) TokenizeStream(Tokenize_TokenizeStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method TokenizeStream not implemented")
+}
 func (UnimplementedTokenizeServer) mustEmbedUnimplementedTokenizeServer() {}
 
 // UnsafeTokenizeServer may be embedded to opt out of forward compatibility for this service.
@@ -272,6 +379,32 @@ func _Tokenize_Tokenize_Handler(srv interface{}, ctx context.Context, dec func(i
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Tokenize_TokenizeStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(TokenizeServer).TokenizeStream(&tokenizeTokenizeStreamServer{stream})
+}
+
+type Tokenize_TokenizeStreamServer interface {
+	Send(*EncodeResponse) error
+	Recv() (*EncodeRequest, error)
+	grpc.ServerStream
+}
+
+type tokenizeTokenizeStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *tokenizeTokenizeStreamServer) Send(m *EncodeResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *tokenizeTokenizeStreamServer) Recv() (*EncodeRequest, error) {
+	m := new(EncodeRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // Tokenize_ServiceDesc is the grpc.ServiceDesc for Tokenize service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -284,6 +417,13 @@ var Tokenize_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _Tokenize_Tokenize_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "TokenizeStream",
+			Handler:       _Tokenize_TokenizeStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
 	Metadata: "tei.proto",
 }
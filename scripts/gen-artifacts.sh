@@ -12,6 +12,8 @@ trap _cleanup EXIT
 
 # Parse command line arguments
 DISTANCE_METRIC="Cosine"
+PAYLOAD_FIELDS=""
+COLLECTION="main"
 while [[ $# -gt 0 ]]; do
     key="$1"
     case $key in
@@ -27,6 +29,14 @@ while [[ $# -gt 0 ]]; do
         DISTANCE_METRIC="$2"
         shift
         ;;
+        --collection)
+        COLLECTION="$2"
+        shift
+        ;;
+        --payload-fields)
+        PAYLOAD_FIELDS="$2"
+        shift
+        ;;
         --output-dir)
         OUTPUT_DIR="$2"
         shift
@@ -43,39 +53,23 @@ done
 # Create the output directory
 mkdir -p ${OUTPUT_DIR}
 
-# Generate Bolt DB of targets/scores
-go run ${ROOT_DIR}/main.go load --points-data-path ${POINTS_DATA_PATH} --scores-data-path ${SCORES_DATA_PATH} --db-path ${OUTPUT_DIR}/scores.db
-
-# Generate embeddings.snapshot
+# Start a throwaway Qdrant server
 TMPDIR=$(mktemp -d)
 echo ${TMPDIR}
-QDRANT_CNT=$(docker run -d -e QDRANT__STORAGE__STORAGE_PATH=/tmp/storage -e QDRANT__STORAGE__SNAPSHOTS_PATH=/tmp/snapshots -it -p 6335:6333 --rm -u "$(id -u)" -v ${TMPDIR}:/tmp/snapshots ghcr.io/qdrant/qdrant/qdrant:v1.9.0-unprivileged ./qdrant)
+QDRANT_CNT=$(docker run -d -e QDRANT__STORAGE__STORAGE_PATH=/tmp/storage -e QDRANT__STORAGE__SNAPSHOTS_PATH=/tmp/snapshots -it -p 6335:6333 -p 6336:6334 --rm -u "$(id -u)" -v ${TMPDIR}:/tmp/snapshots ghcr.io/qdrant/qdrant/qdrant:v1.9.0-unprivileged ./qdrant)
 
 # Wait for Qdrant
 timeout 1m bash -c 'until curl -s http://localhost:6335/readyz; do sleep 1; done'
 
-# Create a collection
-curl --fail -X PUT http://localhost:6335/collections/main \
-  -H 'Content-Type: application/json' \
-  --data-raw "{
-    \"vectors\": {
-      \"size\": $(head -n 1 ${POINTS_DATA_PATH} | jq '.embedding | length'),
-      \"distance\": \"${DISTANCE_METRIC}\"
-    } 
-  }"
-
-# Batch upsert points
-jq -c '{id: .point_uid, vector: .embedding, payload: {}}' ${POINTS_DATA_PATH} | split -l 500 - ${TMPDIR}/points_part_
-for f in ${TMPDIR}/points_part_*; do
-    jq -cs '{operations: [{upsert: {points: .}}]}' ${f} > ${f}.payload
-    curl -X POST http://localhost:6335/collections/main/points/batch \
-      -H 'Content-Type: application/json' \
-      --data-binary "@${f}.payload"
-done
-
-# Save snapshot
-curl --fail -X POST http://localhost:6335/collections/main/snapshots \
-    -H 'Content-Type: application/json'
-
-# Move snapshot to the output directory
-mv ${TMPDIR}/main/main-*.snapshot ${OUTPUT_DIR}/embeddings.snapshot
+# Generate Bolt DB of targets/scores, and embeddings.snapshot
+go run ${ROOT_DIR}/main.go load \
+    --points-data-path ${POINTS_DATA_PATH} \
+    --scores-data-path ${SCORES_DATA_PATH} \
+    --db-path ${OUTPUT_DIR}/scores.db \
+    --qdrant-address localhost:6336 \
+    --qdrant-http-url http://localhost:6335 \
+    --distance-metric ${DISTANCE_METRIC} \
+    --payload-fields "${PAYLOAD_FIELDS}" \
+    --collection ${COLLECTION} \
+    --snapshot \
+    --output-dir ${OUTPUT_DIR}